@@ -2,6 +2,8 @@ package anthive
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -17,12 +19,15 @@ const (
 	REVERSED = -1 // directed, REVERSED path (from end to start)
 	BLOCKED  = 0  // blocked path (from start to end)
 	STABLE   = 1  // double directed path
+	CLOSED   = 2  // one-way path in opposite direction, can't be used
 )
 
 // The found paths are saved in Result. Using for write result to writer
 type Result struct {
-	AntsCount int
-	Paths     []*list
+	AntsCount    int
+	Paths        []*list
+	Map          *Map          // Snapshot of the solved anthive
+	Improvements []Improvement // Changes of paths by local search, if it's enabled (Options)
 }
 
 // Stores information about the graph, the data being read, and the result. Using for find paths
@@ -32,20 +37,47 @@ type anthive struct {
 	// Main Data
 	AntsCount  int
 	Start, End string
+	Starts     []string       // All start rooms in reading order, Start is the first of them
+	Ends       []string       // All end rooms in reading order, End is the first of them
+	StartAnts  map[string]int // Count of ants of start rooms which have it (##start N)
+	Colonies   []*colony      // Colonies in order of declaration, ants of colony go from its start room to its end room
 	Rooms      map[string]*room
+	RoomsOrder []*room    // Rooms in reading order
+	Links      [][2]*room // Relations in reading order, without duplicates. One-way path goes from 0 to 1 room
+	Weighted   bool       // Some paths have length > 1
+	Wide       bool       // Some rooms have capacity > 1
+	Galleries  bool       // Some paths have capacity other than default
+	Directed   bool       // Some paths are one-way
+	Timed      bool       // Some rooms or paths are closed on some turns
+	Arrivals   []Arrival  // Turns when ants come into start room (##arrivals), nil if all ants are there from the first turn
+	Foraging   bool       // Ants go to end room and back to start room (##foraging)
+	Rules      Rules      // Rules of the lem-in variant for reading and moves
 	// Results
 	StepsCount int
 	Result     *Result
 }
 
 type room struct {
-	Name                string        // Name
-	X, Y                int           // Coordinates
-	Paths               map[*room]int // Paths with state -> REVERSED || BLOCKED || STABLE
-	ParentIn, ParentOut *room         // Store parents for new path
-	VisitIn, VisitOut   bool          // Flag for checking while traversing
-	Weight              [2]int        // Out weight in 0 index, In in 1
-	Separated           bool          // Flag for checking separated node
+	Name                string            // Name
+	X, Y                int               // Coordinates
+	Capacity            int               // Count of ants which room can have at the same time
+	Paths               map[*room]int     // Paths with state -> REVERSED || BLOCKED || STABLE || CLOSED
+	Closed              map[*room]bool    // One-way paths which can't be used from this room
+	Lengths             map[*room]int     // Length of paths in turns, missing means 1
+	Capacities          map[*room]int     // Capacity of paths in ants per turn, missing means default
+	Closures            []Turns           // Turns when room is closed
+	TunnelClosures      map[*room][]Turns // Turns when paths are closed
+	ParentIn, ParentOut *room             // Store parents for new path
+	VisitIn, VisitOut   bool              // Flag for checking while traversing
+	Weight              [2]int            // Out weight in 0 index, In in 1
+	Separated           bool              // Flag for checking separated node
+}
+
+// Colony of ants with its own start and end rooms (##colony name N)
+type colony struct {
+	Name       string
+	Ants       int
+	Start, End string
 }
 
 // with fieldInfo, we understand What data we fill in for the anthive
@@ -53,33 +85,30 @@ type fieldInfo struct {
 	MODE             byte                 // FIELD_ANTS | FIELD_ROOMS | FIELD_PATHS
 	Start, End       bool                 // Should Be True
 	IsStart, IsEnd   bool                 // For Know Which Room is Reading
+	Capacity         int                  // Capacity of the next room by ##capacity, 0 if it isn't set
+	StartAnts        int                  // Count of ants of the next start room by ##start N, 0 if it isn't set
+	Colony           string               // Colony of the next start or end room by ##start name or ##end name
+	Closed           []Turns              // Turns when the next room or path is closed by ##closed
 	UsingCoordinates map[int]map[int]bool // Chekking for unique Coordinates on Rooms
 }
 
 // List of node which has room.
 type node struct {
 	Room *room
+	Dist int // Turns from start room to this room
 	Next *node
 }
 
 // List of Room nodes. Used to store found paths
 type list struct {
-	Len   int
-	Front *node
-	Back  *node
-}
-
-type antStruct struct {
-	Num  int
-	Path int
-	Pos  int
-	Next *antStruct
-}
-
-// queue of ants. Used for write ant position on every step
-type antQueue struct {
-	Front *antStruct
-	Back  *antStruct
+	Len    int
+	Dist   int   // Turns from start room to end room
+	Direct bool  // Path from start to end without rooms and limit of ants, all ants go together
+	Start  *room // Start room of path, nil means Start of anthive
+	Front  *node
+	Back   *node
+	// Steps when ants go from start room by the path (sorted), nil means that path takes next ant every step
+	Departures []int
 }
 
 // for sorting rooms in queue
@@ -103,6 +132,7 @@ func Createanthive() *anthive {
 	result.Rooms = make(map[string]*room)
 	result.FieldInfo = &fieldInfo{UsingCoordinates: make(map[int]map[int]bool)}
 	result.Result = &Result{}
+	result.Rules = DefaultRules
 	return result
 }
 
@@ -122,30 +152,72 @@ func (a *anthive) ValidateByFieldInfo() error {
 			return errors.New("please set ##start room")
 		} else if !a.FieldInfo.End {
 			return errors.New("please set ##end room")
+		} else if a.FieldInfo.Closed != nil {
+			return errors.New("##closed must be before room or path")
 		}
 	}
-	return nil
+	if err := a.validateArrivals(); err != nil {
+		return err
+	} else if err := a.validateForaging(); err != nil {
+		return err
+	} else if err := a.validateColonies(); err != nil {
+		return err
+	}
+	return a.validateStartAnts()
 }
 
 // ReadDataFromLine - reading the line, it replenishes the data about the anthive. (FieldInfo understands what the string is)
 func (a *anthive) ReadDataFromLine(line string) error {
-	if line == "" || strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "##") {
+	if line == "" || strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "##") && !a.isHashLine(line) {
+		return nil
+	} else if a.Rules.UnknownCommands && isUnknownCommand(line) {
+		// command of other lem-in variant
 		return nil
 	}
 	switch a.FieldInfo.MODE {
 	case FIELD_PATHS:
+		if strings.HasPrefix(line, "##closed ") {
+			return a.SetClosedFromLine(line)
+		}
 		err := a.SetPathsFromLine(line)
 		if err != nil {
 			return err
 		}
 	case FIELD_ROOMS:
 		if strings.HasPrefix(line, "##") {
-			if line == "##start" && !a.FieldInfo.Start && !a.FieldInfo.IsStart && !a.FieldInfo.IsEnd {
+			noCommand := !a.FieldInfo.IsStart && !a.FieldInfo.IsEnd && a.FieldInfo.Capacity == 0
+			if line == "##start" && noCommand {
 				a.FieldInfo.IsStart = true
 				return nil
-			} else if line == "##end" && !a.FieldInfo.End && !a.FieldInfo.IsEnd && !a.FieldInfo.IsStart {
+			} else if strings.HasPrefix(line, "##start ") && noCommand {
+				value := strings.TrimPrefix(line, "##start ")
+				// ##start N is count of ants, ##start name is colony
+				if ants, err := strconv.Atoi(value); err != nil {
+					a.FieldInfo.Colony = value
+				} else if ants < 1 {
+					return fmt.Errorf("invalid count of ants of start room. Line: '%v'", line)
+				} else {
+					a.FieldInfo.StartAnts = ants
+				}
+				a.FieldInfo.IsStart = true
+				return nil
+			} else if line == "##end" && noCommand {
 				a.FieldInfo.IsEnd = true
 				return nil
+			} else if strings.HasPrefix(line, "##end ") && noCommand {
+				a.FieldInfo.IsEnd = true
+				a.FieldInfo.Colony = strings.TrimPrefix(line, "##end ")
+				return nil
+			} else if strings.HasPrefix(line, "##capacity ") && noCommand {
+				return a.SetCapacityFromLine(line)
+			} else if strings.HasPrefix(line, "##colony ") && noCommand {
+				return a.AddColonyFromLine(line)
+			} else if strings.HasPrefix(line, "##arrivals ") && noCommand {
+				return a.SetArrivalsFromLine(line)
+			} else if line == "##foraging" && noCommand {
+				return a.SetForaging()
+			} else if strings.HasPrefix(line, "##closed ") && !a.FieldInfo.IsStart && !a.FieldInfo.IsEnd {
+				return a.SetClosedFromLine(line)
 			}
 			return errors.New("error with ## command")
 		}
@@ -154,22 +226,42 @@ func (a *anthive) ReadDataFromLine(line string) error {
 			if err != nil {
 				return err
 			}
+			name := strings.Split(line, " ")[0]
+			if a.FieldInfo.Closed != nil {
+				return errors.New("start and end rooms can't be closed")
+			} else if a.FieldInfo.StartAnts != 0 {
+				err = a.SetStartAnts(name, a.FieldInfo.StartAnts)
+			} else if a.FieldInfo.Colony != "" {
+				err = a.SetColony(name, a.FieldInfo.Colony)
+			}
+			a.FieldInfo.Colony = ""
 			if a.FieldInfo.IsStart {
 				a.FieldInfo.IsStart = false
+				a.FieldInfo.StartAnts = 0
 				a.FieldInfo.Start = true
 			} else {
 				a.FieldInfo.IsEnd = false
 				a.FieldInfo.End = true
 			}
 			return err
-		} else if len(strings.Split(line, " ")) != 3 {
+		} else if splited := strings.Split(line, " "); len(splited) != 3 || strings.ContainsAny(splited[0], "->") {
+			if a.FieldInfo.Capacity != 0 {
+				return errors.New("##capacity must be before room")
+			}
 			a.FieldInfo.MODE = FIELD_PATHS
 			a.FieldInfo.UsingCoordinates = nil
 			return a.ReadDataFromLine(line)
-		} else {
-			_, err := a.SetRoomFromLine(line)
+		}
+		room, err := a.SetRoomFromLine(line)
+		if err != nil {
+			return err
+		}
+		if err = a.setClosed(room.Name, ""); err != nil || a.FieldInfo.Capacity == 0 {
 			return err
 		}
+		err = a.SetCapacity(room.Name, a.FieldInfo.Capacity)
+		a.FieldInfo.Capacity = 0
+		return err
 	case FIELD_ANTS:
 		err := a.SetAntsFromLine(line)
 		if err != nil {
@@ -180,17 +272,51 @@ func (a *anthive) ReadDataFromLine(line string) error {
 	return nil
 }
 
-// Match - Finds paths, returns an error if it does not find a single path. Paths are saved in anthive.Result
-func (a *anthive) Match() error {
+// Match - Finds paths, returns an error if it does not find a single path. Paths are saved in anthive.Result.
+// Options of the run must be prepared by SetOptions, nil means default options.
+// If local search is enabled, then it changes the found paths
+func (a *anthive) Match(o *Options) error {
+	if o == nil {
+		o = &Options{}
+	}
+	err := a.match(o)
+	if err == nil && o.Improve {
+		a.improve(o)
+	}
+	if err == nil {
+		a.Result.Map.SetOptions(*o)
+	}
+	return err
+}
+
+// match - finds paths by the solver of the anthive for options of the run
+func (a *anthive) match(o *Options) error {
+	if len(a.Colonies) > 0 {
+		return a.matchColonies(o)
+	} else if a.Foraging {
+		return a.matchForaging(o)
+	} else if len(o.Waypoints) > 0 {
+		return a.matchWaypoints(o)
+	} else if o.pinned != nil {
+		return a.matchPinned(o)
+	} else if a.Timed || a.Arrivals != nil {
+		return a.matchTimetable(o)
+	} else if a.needsNetwork() {
+		return a.matchNetwork(o)
+	} else if o.Objective != "" && o.Objective != OBJECTIVE_TURNS {
+		return a.matchObjective(o)
+	}
 	for {
-		if !searchShortPath(a) {
+		if !searchShortPath(a, o) {
 			// path not found, then check for prev path count
 			if a.StepsCount > 0 {
+				a.Result.Map = a.Map()
 				return nil
 			}
 			return errors.New("path not found")
 		}
-		if !checkEffective(a) {
+		if !checkEffective(a, o) {
+			a.Result.Map = a.Map()
 			return nil
 		}
 	}
@@ -0,0 +1,99 @@
+package anthive
+
+import "testing"
+
+// readTest - reads the map, the test fails if the map isn't valid
+func readTest(t *testing.T, name, content string) *anthive {
+	t.Helper()
+	a, err := ReadAnthive(content, FORMAT_MAP)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return a
+}
+
+// solveTest - reads and solves the map with options (nil means default options), checks moves of the result by the map
+// and their count of turns. Returns the solved anthive and its moves
+func solveTest(t *testing.T, name, content string, o *Options, turns int) (*anthive, [][]Move) {
+	t.Helper()
+	a := readTest(t, name, content)
+	if o != nil {
+		if err := a.SetOptions(o); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	if err := a.Match(o); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	moves := a.Result.Moves()
+	if err := a.Result.Map.Verify(moves); err != nil {
+		t.Errorf("%s: %v", name, err)
+	}
+	if len(moves) != turns {
+		t.Errorf("%s: %d turns, want %d", name, len(moves), turns)
+	}
+	return a, moves
+}
+
+// antRooms - returns rooms of ants after every turn by ant number, ants which haven't moved yet are in start room and aren't written
+func antRooms(moves [][]Move) []map[int]string {
+	rooms := make([]map[int]string, len(moves))
+	cur := make(map[int]string)
+	for turn, step := range moves {
+		for _, move := range step {
+			cur[move.Ant] = move.Room
+		}
+		rooms[turn] = make(map[int]string, len(cur))
+		for ant, room := range cur {
+			rooms[turn][ant] = room
+		}
+	}
+	return rooms
+}
+
+// maxAnts - returns the most count of ants which are in the room at the same turn
+func maxAnts(rooms []map[int]string, room string) int {
+	result := 0
+	for _, turn := range rooms {
+		count := 0
+		for _, r := range turn {
+			if r == room {
+				count++
+			}
+		}
+		if count > result {
+			result = count
+		}
+	}
+	return result
+}
+
+// entries - returns count of ants which go from room into room on every turn, ants start in start room
+func entries(start string, moves [][]Move, from, to string) []int {
+	result := make([]int, len(moves))
+	cur := make(map[int]string)
+	for turn, step := range moves {
+		for _, move := range step {
+			prev, ok := cur[move.Ant]
+			if !ok {
+				prev = start
+			}
+			if prev == from && move.Room == to {
+				result[turn]++
+			}
+			cur[move.Ant] = move.Room
+		}
+	}
+	return result
+}
+
+// maxEntries - returns the most count of ants which go from room into room at the same turn, ants start in start room
+func maxEntries(start string, moves [][]Move, from, to string) int {
+	result := 0
+	for _, count := range entries(start, moves, from, to) {
+		if count > result {
+			result = count
+		}
+	}
+	return result
+}
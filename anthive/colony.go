@@ -0,0 +1,189 @@
+package anthive
+
+import (
+	"errors"
+	"sort"
+)
+
+// Solver for the maps with colonies (heuristic). Two plans are made, the plan with less count of turns is chosen.
+//
+// Separate paths: count of turns is searched by bisection. For the count of turns colonies are routed one after another:
+// every colony takes the fewest paths of its min cost flow which bring all its ants in time,
+// places of rooms and tunnels which are taken by paths of previous colonies can't be used by next colonies.
+// If some colony can't be routed, then rooms of previous colonies become more expensive and it goes first next time.
+// Paths of different colonies never share more places than rooms and tunnels have, so ants of colonies don't meet.
+//
+// Timetable: every colony takes its own best paths, paths of colonies can cross and ants are sent by timetable.
+// Only timetable is made if some rooms or tunnels are closed on some turns
+
+// usage - count of paths which go through rooms and tunnels
+type usage struct {
+	Rooms   map[*room]int
+	Tunnels map[[2]*room]int // by tunnelKey
+	Penalty map[*room]int    // extra cost of rooms which are needed by other colonies
+}
+
+// room, tunnel - returns count of paths in the room or tunnel, usage can be nil
+func (u *usage) room(r *room) int {
+	if u == nil {
+		return 0
+	}
+	return u.Rooms[r]
+}
+
+func (u *usage) tunnel(key [2]*room) int {
+	if u == nil {
+		return 0
+	}
+	return u.Tunnels[key]
+}
+
+// penalty - returns extra cost of the room, usage can be nil
+func (u *usage) penalty(r *room) int {
+	if u == nil {
+		return 0
+	}
+	return u.Penalty[r]
+}
+
+// add - takes places of the path, path without rooms and limit doesn't take places
+func (u *usage) add(path *list) {
+	if path.Direct {
+		return
+	}
+	prev := path.Start
+	for node := path.Front; node != nil; node = node.Next {
+		u.Tunnels[tunnelKey(prev, node.Room)]++
+		if node.Next != nil {
+			u.Rooms[node.Room]++
+		}
+		prev = node.Room
+	}
+}
+
+// tunnelKey - returns the same key for both directions of tunnel
+func tunnelKey(room1, room2 *room) [2]*room {
+	if room1.Name > room2.Name {
+		return [2]*room{room2, room1}
+	}
+	return [2]*room{room1, room2}
+}
+
+// matchColonies - finds paths of all colonies with the least count of turns
+func (a *anthive) matchColonies(o *Options) error {
+	var paths []*list
+	steps := 0
+	if !a.Timed {
+		paths, steps = a.separateColonies(o)
+	}
+	if scheduled, scheduledSteps := a.scheduleColonies(o); scheduledSteps > 0 && (steps == 0 || scheduledSteps < steps) {
+		paths, steps = scheduled, scheduledSteps
+	}
+	if steps == 0 {
+		return errors.New("path not found")
+	}
+	a.StepsCount = steps
+	a.Result.Paths = paths
+	a.Result.Map = a.Map()
+	return nil
+}
+
+// separateColonies - returns paths of colonies which don't share more places than rooms and tunnels have, and count of turns.
+// Returns 0 turns if colonies can't be separated
+func (a *anthive) separateColonies(o *Options) ([]*list, int) {
+	// without limit every colony takes its first paths which bring ants to end
+	paths, steps := a.routeColonies(o, 0)
+	if steps == 0 {
+		return nil, 0
+	}
+	low, high := 1, steps-1
+	for low <= high {
+		limit := (low + high) / 2
+		if curPaths, curSteps := a.routeColonies(o, limit); curSteps > 0 {
+			paths, steps = curPaths, curSteps
+			high = curSteps - 1
+		} else {
+			low = limit + 1
+		}
+	}
+	return paths, steps
+}
+
+// routeColonies - returns paths of colonies which bring all ants in limit of turns (0 means without limit) and count of turns.
+// Colonies with more ants go first. Returns 0 turns if limit isn't reached
+func (a *anthive) routeColonies(o *Options, limit int) ([]*list, int) {
+	order := append([]*colony{}, a.Colonies...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Ants > order[j].Ants })
+	penalty := make(map[*room]int)
+	for attempt := 0; attempt < 4*len(order); attempt++ {
+		used := &usage{Rooms: make(map[*room]int), Tunnels: make(map[[2]*room]int), Penalty: penalty}
+		var result []*list
+		steps, failed := 0, -1
+		for i, c := range order {
+			paths, colonySteps := a.routeColony(o, c, used, limit)
+			if colonySteps == 0 {
+				failed = i
+				break
+			}
+			for _, path := range paths {
+				used.add(path)
+			}
+			result = append(result, paths...)
+			if colonySteps > steps {
+				steps = colonySteps
+			}
+		}
+		if failed == -1 {
+			sort.SliceStable(result, func(i, j int) bool { return result[i].Dist < result[j].Dist })
+			return result, steps
+		}
+		// rooms of previous colonies become more expensive, failed colony goes first
+		for r, count := range used.Rooms {
+			penalty[r] += count
+		}
+		order = append(append([]*colony{order[failed]}, order[:failed]...), order[failed+1:]...)
+	}
+	return nil, 0
+}
+
+// routeColony - returns the fewest paths of colony which bring its ants in limit of turns (0 means without limit).
+// Places which are used by other colonies are not available. Returns 0 turns if limit isn't reached
+func (a *anthive) routeColony(o *Options, c *colony, used *usage, limit int) ([]*list, int) {
+	n, source, sink := a.buildNetwork(o, c, used)
+	ends := []string{c.End}
+	paths, steps := a.bestPaths(c.Start, c.Ants, nil, ends)
+	for flow := 0; flow < c.Ants && (steps == 0 || limit != 0 && steps > limit) && n.augment(source, sink); flow++ {
+		paths, steps = a.bestPaths(c.Start, c.Ants, n.paths(a), ends)
+	}
+	if steps == 0 || limit != 0 && steps > limit {
+		return nil, 0
+	}
+	return paths, steps
+}
+
+// bestColonyPaths - returns the best paths of colony as if there are no other colonies, and count of turns
+func (a *anthive) bestColonyPaths(o *Options, c *colony) ([]*list, int) {
+	n, source, sink := a.buildNetwork(o, c, nil)
+	ends := []string{c.End}
+	paths, steps := a.bestPaths(c.Start, c.Ants, nil, ends)
+	for flow := 0; flow < c.Ants && n.augment(source, sink); flow++ {
+		if curPaths, curSteps := a.bestPaths(c.Start, c.Ants, n.paths(a), ends); curSteps > 0 && (steps == 0 || curSteps < steps) {
+			paths, steps = curPaths, curSteps
+		}
+	}
+	return paths, steps
+}
+
+// scheduleColonies - returns the best paths of every colony with steps of departures of ants, and count of turns.
+// Returns 0 turns if some colony can't reach its end room
+func (a *anthive) scheduleColonies(o *Options) ([]*list, int) {
+	groups := make(map[string][]*list)
+	for _, c := range a.Colonies {
+		paths, steps := a.bestColonyPaths(o, c)
+		if steps == 0 {
+			return nil, 0
+		}
+		groups[c.Start] = paths
+	}
+	return a.scheduleAnts(o, groups)
+}
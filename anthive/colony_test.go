@@ -0,0 +1,42 @@
+package anthive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchColonies(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+	}{
+		{"colonies cross in one room", "5\n##colony red 3\n##colony blue 2\n##start red\nr1 0 0\n##end red\nr2 6 0\n##start blue\nb1 3 -3\n##end blue\nb2 3 3\nm 3 0\nr1-m\nm-r2\nb1-m\nm-b2\n", 6},
+		{"colonies don't meet", "4\n##colony red 2\n##colony blue 2\n##start red\nr1 0 0\n##end red\nr2 6 0\n##start blue\nb1 0 4\n##end blue\nb2 6 4\na 3 0\nb 3 4\nr1-a\na-r2\nb1-b\nb-b2\n", 3},
+		{"common room is taken by one colony", "6\n##colony red 4\n##colony blue 2\n##start red\nr1 0 0\n##end red\nr2 6 0\n##start blue\nb1 0 4\n##end blue\nb2 6 4\na 3 0\nb 3 4\nc 3 2\nr1-a\na-r2\nb1-b\nb-b2\nr1-c\nc-r2\nb1-c\nc-b2\n", 3},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		// every ant of colony reaches end room of its colony
+		last := make(map[string]string)
+		for _, step := range moves {
+			for _, move := range step {
+				last[move.Name] = move.Room
+			}
+		}
+		for _, c := range a.Colonies {
+			arrived := 0
+			for name, room := range last {
+				if strings.HasPrefix(name, c.Name+".") {
+					if room != c.End {
+						t.Errorf("%s: ant %s stops in '%s', want '%s'", test.name, name, room, c.End)
+					}
+					arrived++
+				}
+			}
+			if arrived != c.Ants {
+				t.Errorf("%s: %d ants of colony %s, want %d", test.name, arrived, c.Name, c.Ants)
+			}
+		}
+	}
+}
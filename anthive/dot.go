@@ -0,0 +1,525 @@
+package anthive
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rules for DOT graph:
+// Graph must be undirected: graph { ... }
+// Count of ants is graph attribute: ants=N
+// Ants can come into start room on turns: graph attribute arrivals="1:3 4:2" or arrivals="rate=2"
+// Ants go to end room and back to start room if graph has attribute foraging=true
+// Start and End rooms are nodes with attributes start=true and end=true, there can be several of them.
+// Start room can have count of ants: ants=N
+// Start and end rooms of colony have attribute colony=name, count of ants of colony is ants attribute of its start room
+// Coordinates are taken from pos="x,y" attribute, nodes without pos are placed by their order
+// Length of tunnel is taken from len attribute of edge, 1 by default
+// Capacity of room is taken from capacity attribute of node, 1 by default
+// Capacity of tunnel (ants per turn) is taken from capacity attribute of edge
+// Room and tunnel can be closed on turns: closed="5-9 12"
+// One-way tunnel is edge with attribute dir=forward (from left node to right) or dir=back
+
+// dotToken - token of DOT language. Quoted is true for "strings"
+type dotToken struct {
+	Text   string
+	Quoted bool
+}
+
+// dotNode - node of DOT graph with attributes
+type dotNode struct {
+	Name  string
+	Attrs map[string]string
+}
+
+// dotEdge - edge of DOT graph with attributes
+type dotEdge struct {
+	From, To string
+	Attrs    map[string]string
+}
+
+// ReadDOT - builds anthive from content of DOT graph
+func ReadDOT(content string) (*anthive, error) {
+	return readDOT(content, DefaultRules)
+}
+
+// readDOT - builds anthive from content of DOT graph by rules
+func readDOT(content string, rules Rules) (*anthive, error) {
+	tokens, err := dotTokenize(content)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{tokens: tokens, nodes: make(map[string]*dotNode), graphAttrs: make(map[string]string)}
+	if err = p.parseGraph(); err != nil {
+		return nil, err
+	}
+
+	a := Createanthive()
+	a.Rules = rules
+	ants, err := strconv.Atoi(p.graphAttrs["ants"])
+	if err != nil {
+		return nil, errors.New("invalid number of Ants, set graph attribute ants=N")
+	}
+	if err = a.SetAnts(ants); err != nil {
+		return nil, err
+	}
+	if value, ok := p.graphAttrs["arrivals"]; ok {
+		if err = a.SetArrivals(value); err != nil {
+			return nil, err
+		}
+	}
+	a.Foraging = p.graphAttrs["foraging"] == "true"
+	// at first rooms with coordinates, so that placed rooms don't take their coordinates
+	coords := make(map[string][2]int)
+	for _, node := range p.order {
+		if pos, ok := node.Attrs["pos"]; ok {
+			x, y, err := parseDOTPos(pos)
+			if err != nil {
+				return nil, fmt.Errorf("%v; node: '%v'", err, node.Name)
+			}
+			coords[node.Name] = [2]int{x, y}
+		}
+	}
+	used := make(map[[2]int]bool)
+	for _, c := range coords {
+		used[c] = true
+	}
+	next := 0
+	for _, node := range p.order {
+		c, ok := coords[node.Name]
+		if !ok {
+			for used[[2]int{next, 0}] {
+				next++
+			}
+			c = [2]int{next, 0}
+			used[c] = true
+		}
+		isStart, isEnd := dotTrue(node.Attrs["start"]), dotTrue(node.Attrs["end"])
+		if isStart && isEnd {
+			return nil, fmt.Errorf("room can't be start and end; node: '%v'", node.Name)
+		} else if isStart || isEnd {
+			err = a.AddMainRoom(node.Name, c[0], c[1], isStart)
+		} else {
+			_, err = a.AddRoom(node.Name, c[0], c[1])
+		}
+		// ids are checked as room names of the map, so the map can be written back
+		if err != nil {
+			return nil, fmt.Errorf("%v; node: '%v'", err, node.Name)
+		}
+		colony, isColony := node.Attrs["colony"]
+		if isColony {
+			if err = a.SetColony(node.Name, colony); err != nil {
+				return nil, fmt.Errorf("%v; node: '%v'", err, node.Name)
+			}
+		}
+		if value, ok := node.Attrs["ants"]; ok {
+			ants, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ants '%v'; node: '%v'", value, node.Name)
+			}
+			if isColony && isStart {
+				err = a.AddColony(colony, ants)
+			} else {
+				err = a.SetStartAnts(node.Name, ants)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%v; node: '%v'", err, node.Name)
+			}
+		}
+		if value, ok := node.Attrs["capacity"]; ok {
+			capacity, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid capacity '%v'; node: '%v'", value, node.Name)
+			} else if err = a.SetCapacity(node.Name, capacity); err != nil {
+				return nil, fmt.Errorf("%v; node: '%v'", err, node.Name)
+			}
+		}
+		if value, ok := node.Attrs["closed"]; ok {
+			if err = a.setClosedTurns(node.Name, "", value); err != nil {
+				return nil, fmt.Errorf("%v; node: '%v'", err, node.Name)
+			}
+		}
+	}
+	for _, edge := range p.edges {
+		length := 1
+		if value, ok := edge.Attrs["len"]; ok {
+			if length, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid len '%v'. Edge: '%v -- %v'", value, edge.From, edge.To)
+			}
+		}
+		switch edge.Attrs["dir"] {
+		case "forward":
+			err = a.AddOneWayLink(edge.From, edge.To, length)
+		case "back":
+			err = a.AddOneWayLink(edge.To, edge.From, length)
+		case "", "none", "both":
+			err = a.AddLink(edge.From, edge.To, length)
+		default:
+			err = fmt.Errorf("invalid dir '%v'", edge.Attrs["dir"])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v. Edge: '%v -- %v'", err, edge.From, edge.To)
+		}
+		if value, ok := edge.Attrs["capacity"]; ok {
+			capacity, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid capacity '%v'. Edge: '%v -- %v'", value, edge.From, edge.To)
+			} else if err = a.SetLinkCapacity(edge.From, edge.To, capacity); err != nil {
+				return nil, fmt.Errorf("%v. Edge: '%v -- %v'", err, edge.From, edge.To)
+			}
+		}
+		if value, ok := edge.Attrs["closed"]; ok {
+			if err = a.setClosedTurns(edge.From, edge.To, value); err != nil {
+				return nil, fmt.Errorf("%v. Edge: '%v -- %v'", err, edge.From, edge.To)
+			}
+		}
+	}
+	if err = a.Validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// dotTrue - returns true if value of attribute means true
+func dotTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "1":
+		return true
+	}
+	return false
+}
+
+// parseDOTPos - parses pos attribute: "x,y" or "x,y!". Coordinates are rounded to integers
+func parseDOTPos(pos string) (int, int, error) {
+	splited := strings.Split(strings.TrimSuffix(pos, "!"), ",")
+	if len(splited) < 2 {
+		return 0, 0, fmt.Errorf("invalid pos '%v'", pos)
+	}
+	x, errX := strconv.ParseFloat(strings.TrimSpace(splited[0]), 64)
+	y, errY := strconv.ParseFloat(strings.TrimSpace(splited[1]), 64)
+	if errX != nil || errY != nil {
+		return 0, 0, fmt.Errorf("invalid pos '%v'", pos)
+	}
+	return int(math.Round(x)), int(math.Round(y)), nil
+}
+
+// dotTokenize - splits DOT content into tokens, comments are skipped
+func dotTokenize(content string) ([]dotToken, error) {
+	var tokens []dotToken
+	runes := []rune(content)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' && (i == 0 || runes[i-1] == '\n'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, errors.New("unclosed comment")
+			}
+			i += 2
+		case r == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					if runes[i+1] == '"' {
+						i++
+					} else if runes[i+1] == '\n' {
+						i++
+						continue
+					}
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unclosed string")
+			}
+			i++
+			tokens = append(tokens, dotToken{Text: b.String(), Quoted: true})
+		case r == '-' && i+1 < len(runes) && (runes[i+1] == '-' || runes[i+1] == '>'):
+			tokens = append(tokens, dotToken{Text: string(runes[i : i+2])})
+			i += 2
+		case strings.ContainsRune("{}[];,=:", r):
+			tokens = append(tokens, dotToken{Text: string(r)})
+			i++
+		case r == '<':
+			// HTML string, nested brackets are allowed
+			depth, start := 0, i
+			for ; i < len(runes); i++ {
+				if runes[i] == '<' {
+					depth++
+				} else if runes[i] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unclosed html string")
+			}
+			tokens = append(tokens, dotToken{Text: string(runes[start+1 : i]), Quoted: true})
+			i++
+		case r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				runes[i] == '-' && i == start) {
+				i++
+			}
+			tokens = append(tokens, dotToken{Text: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected symbol '%c'", r)
+		}
+	}
+	return tokens, nil
+}
+
+// dotParser - parser of DOT graph. Subgraphs are flattened into the graph
+type dotParser struct {
+	tokens     []dotToken
+	pos        int
+	nodes      map[string]*dotNode
+	order      []*dotNode // nodes in order of appearance
+	edges      []*dotEdge
+	graphAttrs map[string]string
+}
+
+func (p *dotParser) peek() *dotToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// keyword - returns true if next token is keyword (case-insensitive, not quoted)
+func (p *dotParser) keyword(word string) bool {
+	t := p.peek()
+	return t != nil && !t.Quoted && strings.EqualFold(t.Text, word)
+}
+
+// punct - returns true if next token is punctuation
+func (p *dotParser) punct(s string) bool {
+	t := p.peek()
+	return t != nil && !t.Quoted && t.Text == s
+}
+
+func (p *dotParser) expect(s string) error {
+	if !p.punct(s) {
+		return p.errorf("expected '%v'", s)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) errorf(format string, args ...interface{}) error {
+	found := "end of graph"
+	if t := p.peek(); t != nil {
+		found = "'" + t.Text + "'"
+	}
+	return fmt.Errorf("invalid DOT graph, "+format+", found %v", append(args, found)...)
+}
+
+// id - reads identifier
+func (p *dotParser) id() (string, error) {
+	t := p.peek()
+	if t == nil || !t.Quoted && strings.ContainsAny(t.Text, "{}[];,=:") || !t.Quoted && (t.Text == "--" || t.Text == "->") {
+		return "", p.errorf("expected identifier")
+	}
+	p.pos++
+	return t.Text, nil
+}
+
+// parseGraph - [strict] graph [ID] { stmt_list }
+func (p *dotParser) parseGraph() error {
+	if p.keyword("strict") {
+		p.pos++
+	}
+	if p.keyword("digraph") {
+		return errors.New("invalid DOT graph, only undirected graph is supported")
+	} else if !p.keyword("graph") {
+		return p.errorf("expected 'graph'")
+	}
+	p.pos++
+	if !p.punct("{") {
+		if _, err := p.id(); err != nil {
+			return err
+		}
+	}
+	if err := p.parseBlock(); err != nil {
+		return err
+	}
+	if p.peek() != nil {
+		return p.errorf("expected end of graph")
+	}
+	return nil
+}
+
+// parseBlock - { stmt_list }
+func (p *dotParser) parseBlock() error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.punct("}") {
+		if p.peek() == nil {
+			return p.errorf("expected '}'")
+		}
+		if err := p.parseStatement(); err != nil {
+			return err
+		}
+		if p.punct(";") || p.punct(",") {
+			p.pos++
+		}
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) parseStatement() error {
+	switch {
+	case p.keyword("graph"):
+		p.pos++
+		return p.parseAttrs(p.graphAttrs)
+	case p.keyword("node") || p.keyword("edge"):
+		// default attributes are not used by anthive
+		p.pos++
+		return p.parseAttrs(make(map[string]string))
+	case p.keyword("subgraph") || p.punct("{"):
+		_, err := p.parseNodeGroup()
+		return err
+	}
+	names, err := p.parseNodeGroup()
+	if err != nil {
+		return err
+	}
+	if p.punct("=") && len(names) == 1 {
+		p.pos++
+		value, err := p.id()
+		if err != nil {
+			return err
+		}
+		p.graphAttrs[names[0]] = value
+		return nil
+	}
+	if !p.punct("--") && !p.punct("->") {
+		attrs := make(map[string]string)
+		if err := p.parseAttrs(attrs); err != nil {
+			return err
+		}
+		for _, name := range names {
+			for k, v := range attrs {
+				p.nodes[name].Attrs[k] = v
+			}
+		}
+		return nil
+	}
+	attrs := make(map[string]string)
+	for p.punct("--") || p.punct("->") {
+		if p.punct("->") {
+			return p.errorf("directed edges are not supported")
+		}
+		p.pos++
+		next, err := p.parseNodeGroup()
+		if err != nil {
+			return err
+		}
+		for _, from := range names {
+			for _, to := range next {
+				p.edges = append(p.edges, &dotEdge{From: from, To: to, Attrs: attrs})
+			}
+		}
+		names = next
+	}
+	return p.parseAttrs(attrs)
+}
+
+// parseNodeGroup - node ID (with optional port) or subgraph, returns names of nodes
+func (p *dotParser) parseNodeGroup() ([]string, error) {
+	if p.keyword("subgraph") || p.punct("{") {
+		if p.keyword("subgraph") {
+			p.pos++
+			if !p.punct("{") {
+				if _, err := p.id(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		before := len(p.order)
+		if err := p.parseBlock(); err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(p.order)-before)
+		for _, node := range p.order[before:] {
+			names = append(names, node.Name)
+		}
+		return names, nil
+	}
+	name, err := p.id()
+	if err != nil {
+		return nil, err
+	}
+	if p.punct("=") {
+		// it's attribute statement ID = ID, node isn't created
+		return []string{name}, nil
+	}
+	for p.punct(":") {
+		p.pos++
+		if _, err = p.id(); err != nil {
+			return nil, err
+		}
+	}
+	p.node(name)
+	return []string{name}, nil
+}
+
+// node - returns node by name, creates it if not exists
+func (p *dotParser) node(name string) *dotNode {
+	if node, ok := p.nodes[name]; ok {
+		return node
+	}
+	node := &dotNode{Name: name, Attrs: make(map[string]string)}
+	p.nodes[name] = node
+	p.order = append(p.order, node)
+	return node
+}
+
+// parseAttrs - [ a=b, c=d ] [ ... ], attributes are saved into attrs
+func (p *dotParser) parseAttrs(attrs map[string]string) error {
+	for p.punct("[") {
+		p.pos++
+		for !p.punct("]") {
+			key, err := p.id()
+			if err != nil {
+				return err
+			}
+			value := "true"
+			if p.punct("=") {
+				p.pos++
+				if value, err = p.id(); err != nil {
+					return err
+				}
+			}
+			attrs[key] = value
+			if p.punct(",") || p.punct(";") {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package anthive
+
+import "testing"
+
+func TestReadDOTRoomNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"quoted id", "graph { ants=1\n \"a\" [start=true]\n \"c\" [end=true]\n a -- c\n}\n", ""},
+		{"id with spaces", "graph { ants=1\n a [start=true]\n c [end=true]\n a -- c\n \"q r\"\n}\n", "room name can't have spaces; node: 'q r'"},
+		{"id with '-'", "graph { ants=1\n a [start=true]\n c [end=true]\n a -- c\n \"q-r\"\n}\n", "room name can't have '-'; node: 'q-r'"},
+		{"id of ant", "graph { ants=1\n a [start=true]\n c [end=true]\n a -- c\n L1\n}\n", "room name can't be started with 'L'; node: 'L1'"},
+	}
+	for _, test := range tests {
+		_, err := ReadAnthive(test.content, FORMAT_DOT)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package anthive
+
+// Edge-disjoint mode: rooms have no limit of ants, every tunnel takes one ant per turn (paths can share rooms, but not tunnels).
+// Mode is set by capacities: every room (except start and end rooms) gets capacity of all ants,
+// and tunnel between start and end room without capacity gets capacity 1, so all solvers, scheduler and verifier use the same rules.
+// Tunnels with capacity keep it
+
+// Rules for Edges:
+// Rooms can have any count of ants, tunnel without capacity takes one ant per turn (tunnel between start and end room too).
+// Given paths must be edge-disjoint instead of vertex-disjoint
+
+// setEdgeDisjoint - gives capacity of all ants to rooms and capacity 1 to tunnels between start and end rooms without capacity
+func (a *anthive) setEdgeDisjoint() error {
+	for _, r := range a.RoomsOrder {
+		if a.isStart(r.Name) || a.isEnd(r.Name) {
+			continue
+		} else if err := a.SetCapacity(r.Name, a.AntsCount); err != nil {
+			return err
+		}
+	}
+	for _, l := range a.Links {
+		if _, ok := l[0].Capacities[l[1]]; ok || !a.isDirect(l[0], l[1]) {
+			continue
+		} else if err := a.SetLinkCapacity(l[0].Name, l[1].Name, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package anthive
+
+import "testing"
+
+// sharedRoomMap - two paths share room m, so they are edge-disjoint, but not vertex-disjoint
+const sharedRoomMap = "4\n##start\ns 0 0\na 1 0\nb 1 2\nm 2 1\nc 3 0\nd 3 2\n##end\ne 4 1\ns-a\ns-b\na-m\nb-m\nm-c\nm-d\nc-e\nd-e\n"
+
+func TestMatchEdgeDisjoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		paths   [][]string
+		turns   int
+		room    string
+		ants    int // the most ants in the room at the same turn
+	}{
+		{"paths share room", sharedRoomMap, nil, 5, "m", 2},
+		{"given paths share room", sharedRoomMap, [][]string{{"s", "a", "m", "c", "e"}, {"s", "b", "m", "d", "e"}}, 5, "m", 2},
+		{"tunnel between start and end", "3\n##start\ns 0 0\n##end\ne 1 0\ns-e\n", nil, 3, "e", 3},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, &Options{Edges: true, Paths: test.paths}, test.turns)
+		if ants := maxAnts(antRooms(moves), test.room); ants != test.ants {
+			t.Errorf("%s: %d ants in room '%s' at once, want %d", test.name, ants, test.room, test.ants)
+		}
+		// every tunnel takes one ant per turn
+		for _, link := range a.Result.Map.Links {
+			for _, tunnel := range [][2]string{{link.From, link.To}, {link.To, link.From}} {
+				if ants := maxEntries(a.Start, moves, tunnel[0], tunnel[1]); ants > 1 {
+					t.Errorf("%s: %d ants go into tunnel '%s-%s' at once", test.name, ants, tunnel[0], tunnel[1])
+				}
+			}
+		}
+		// rooms of one ant don't allow these moves
+		if test.room != a.End {
+			if err := readTest(t, test.name, test.content).Map().Verify(moves); err == nil {
+				t.Errorf("%s: moves are accepted without edge-disjoint mode", test.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package anthive
+
+import (
+	"errors"
+	"sort"
+)
+
+// Solver for foraging (round trips): every ant goes from start room to end room and back to one of start rooms.
+// Paths to ends are taken from min cost flow of the anthive, paths back are taken from min cost flow from every end room to start rooms.
+// Every path to end room is joined with every path back from this end room, and ants are sent by timetable,
+// so ants of both ways share rooms and tunnels. Ant doesn't wait in end room, it waits in start room for the whole round trip
+
+// matchForaging - finds paths to ends and back by min cost flow for every count of paths and sends ants by round trips with timetable
+func (a *anthive) matchForaging(o *Options) error {
+	there, source, sink := a.buildNetwork(o, nil, nil)
+	backs := make([]*network, len(a.Ends))
+	for i, end := range a.Ends {
+		backs[i] = a.returnNetwork(o, end)
+	}
+	// source and sink of networks of the way back
+	backSource, backSink := 2*len(a.RoomsOrder), 2*len(a.RoomsOrder)+1
+	var paths []*list
+	steps := 0
+	for flow := 0; ; flow++ {
+		trips := a.roundTrips(a.groupPaths(there.paths(a)), a.returnPaths(backs))
+		if curPaths, curSteps := a.scheduleAnts(o, trips); curSteps > 0 && (steps == 0 || curSteps < steps) {
+			paths, steps = curPaths, curSteps
+		}
+		more := there.augment(source, sink)
+		for _, back := range backs {
+			more = back.augment(backSource, backSink) || more
+		}
+		if flow >= a.AntsCount || !more {
+			break
+		}
+	}
+	if steps == 0 {
+		return errors.New("path not found")
+	}
+	a.StepsCount = steps
+	a.Result.Paths = paths
+	a.Result.Map = a.Map()
+	return nil
+}
+
+// returnNetwork - returns network of the way back from the end room: source (2*len(RoomsOrder)) is linked with the end room,
+// start rooms are linked with sink (2*len(RoomsOrder)+1). Tunnel between start and end without capacity isn't added
+func (a *anthive) returnNetwork(o *Options, end string) *network {
+	index := make(map[*room]int, len(a.RoomsOrder))
+	for i, r := range a.RoomsOrder {
+		index[r] = i
+	}
+	n := &network{Adj: make([][]int, 2*len(a.RoomsOrder)+2)}
+	source, sink := 2*len(a.RoomsOrder), 2*len(a.RoomsOrder)+1
+	for i, r := range a.RoomsOrder {
+		if r.Name == end {
+			n.addArc(source, nodeOut(i), a.AntsCount, 0, false)
+		} else if a.isEnd(r.Name) {
+			continue
+		} else if a.isStart(r.Name) {
+			n.addArc(nodeIn(i), sink, a.AntsCount, 0, false)
+		} else {
+			n.addArc(nodeIn(i), nodeOut(i), r.Capacity, 0, false)
+		}
+	}
+	a.addTunnels(o, n, index, nil)
+	return n
+}
+
+// returnPaths - returns paths of flows of networks of the way back with paths without rooms and limit, Start of path is end room
+func (a *anthive) returnPaths(backs []*network) []*list {
+	var result []*list
+	for _, n := range backs {
+		result = append(result, n.pathsBetween(a, a.isEnd, a.isStart)...)
+	}
+	for _, end := range a.Ends {
+		endRoom := a.Rooms[end]
+		for _, start := range a.Starts {
+			startRoom := a.Rooms[start]
+			if !endRoom.canGo(startRoom) || endRoom.Capacities[startRoom] != 0 {
+				continue
+			}
+			path := &list{Start: endRoom, Dist: endRoom.length(startRoom), Direct: true}
+			path.PushBack(startRoom)
+			path.Back.Dist = path.Dist
+			result = append(result, path)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Dist < result[j].Dist })
+	return result
+}
+
+// roundTrips - returns round trips of every group: every path of the group is joined with every path back from its end room
+func (a *anthive) roundTrips(groups map[string][]*list, returns []*list) map[string][]*list {
+	result := make(map[string][]*list)
+	for group, paths := range groups {
+		for _, there := range paths {
+			for _, back := range returns {
+				if back.Start == there.Back.Room {
+					result[group] = append(result[group], roundTrip(there, back))
+				}
+			}
+		}
+		sort.SliceStable(result[group], func(i, j int) bool { return result[group][i].Dist < result[group][j].Dist })
+	}
+	return result
+}
+
+// roundTrip - returns path which goes by the first path to end room and then by the second path back to start room
+func roundTrip(there, back *list) *list {
+	result := &list{Start: there.Start, Dist: there.Dist + back.Dist}
+	for node := there.Front; node != nil; node = node.Next {
+		result.PushBack(node.Room)
+		result.Back.Dist = node.Dist
+	}
+	for node := back.Front; node != nil; node = node.Next {
+		result.PushBack(node.Room)
+		result.Back.Dist = there.Dist + node.Dist
+	}
+	return result
+}
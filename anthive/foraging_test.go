@@ -0,0 +1,36 @@
+package anthive
+
+import "testing"
+
+func TestMatchForaging(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+	}{
+		{"ants share the only path both ways", "2\n##foraging\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e\n", 8},
+		{"ants go back by short path", "3\n##foraging\n##start\ns 0 0\na 1 0\nb 1 2\nc 2 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e\n", 6},
+		{"tunnel between start and end without limit", "2\n##foraging\n##start\ns1 0 0\n##start\ns2 0 2\na 1 0\n##end\ne 2 0\ns1-a\na-e\ns2-e\n", 2},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		// every ant reaches end room, then it goes back to start room
+		there, back := make(map[int]string), make(map[int]string)
+		for turn, step := range moves {
+			for _, move := range step {
+				if !move.Back && back[move.Ant] != "" {
+					t.Errorf("%s: ant %d goes to end room on turn %d after the way back", test.name, move.Ant, turn+1)
+				} else if move.Back {
+					back[move.Ant] = move.Room
+				} else {
+					there[move.Ant] = move.Room
+				}
+			}
+		}
+		for ant := 1; ant <= a.AntsCount; ant++ {
+			if !a.isEnd(there[ant]) || !a.isStart(back[ant]) {
+				t.Errorf("%s: ant %d goes to '%s' and back to '%s'", test.name, ant, there[ant], back[ant])
+			}
+		}
+	}
+}
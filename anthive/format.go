@@ -0,0 +1,139 @@
+package anthive
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatItem - line of the map with comments (and ## commands) before it
+type formatItem struct {
+	Comments []string
+	Line     string
+}
+
+// Format - returns the map (lem-in format) in canonical form:
+// ants, ##colony commands, ##arrivals, ##foraging, ##start rooms, ##end rooms, other rooms in reading order, tunnels without duplicates as a-b with sorted names
+// (and length, capacity if they aren't default).
+// Comments are kept before their lines, empty lines are removed
+func Format(content string) (string, error) {
+	a, err := ReadAnthive(content, FORMAT_MAP)
+	if err != nil {
+		return "", err
+	}
+	var ants *formatItem
+	var starts, ends, rooms, links []*formatItem
+	linkIndex := make(map[string]*formatItem)
+	var pending []string
+	mode := FIELD_ANTS
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" || line == "##start" || strings.HasPrefix(line, "##start ") || line == "##end" ||
+			strings.HasPrefix(line, "##end ") || strings.HasPrefix(line, "##colony ") || strings.HasPrefix(line, "##arrivals ") || line == "##foraging" {
+			continue
+		} else if strings.HasPrefix(line, "#") {
+			pending = append(pending, line)
+			continue
+		}
+		item := &formatItem{Comments: pending}
+		pending = nil
+		fields := strings.Split(line, " ")
+		switch {
+		case mode == FIELD_ANTS:
+			item.Line = strconv.Itoa(a.AntsCount)
+			ants = item
+			mode = FIELD_ROOMS
+		case mode == FIELD_ROOMS && len(fields) == 3 && !strings.ContainsAny(fields[0], "->"):
+			name := fields[0]
+			item.Line = formatRoom(a.Rooms[name])
+			if a.isStart(name) {
+				starts = append(starts, item)
+			} else if a.isEnd(name) {
+				ends = append(ends, item)
+			} else {
+				rooms = append(rooms, item)
+			}
+		default:
+			mode = FIELD_PATHS
+			item.Line = formatLink(a, line)
+			if prev, ok := linkIndex[item.Line]; ok {
+				prev.Comments = append(prev.Comments, item.Comments...)
+				continue
+			}
+			linkIndex[item.Line] = item
+			links = append(links, item)
+		}
+	}
+
+	var b strings.Builder
+	write := func(item *formatItem, command string) {
+		for _, comment := range item.Comments {
+			b.WriteString(comment + "\n")
+		}
+		if command != "" {
+			b.WriteString(command + "\n")
+		}
+		b.WriteString(item.Line + "\n")
+	}
+	write(ants, "")
+	for _, c := range a.Colonies {
+		b.WriteString("##colony " + c.Name + " " + strconv.Itoa(c.Ants) + "\n")
+	}
+	if a.Arrivals != nil {
+		b.WriteString("##arrivals " + JoinArrivals(a.Arrivals) + "\n")
+	}
+	if a.Foraging {
+		b.WriteString("##foraging\n")
+	}
+	m := a.Map()
+	for _, item := range starts {
+		name, command := strings.Split(item.Line, " ")[0], "##start"
+		if c := m.RoomColony(name); c != nil {
+			command += " " + c.Name
+		} else if ants, ok := a.StartAnts[name]; ok {
+			command += " " + strconv.Itoa(ants)
+		}
+		write(item, command)
+	}
+	for _, item := range ends {
+		command := "##end"
+		if c := m.RoomColony(strings.Split(item.Line, " ")[0]); c != nil {
+			command += " " + c.Name
+		}
+		write(item, command)
+	}
+	for _, item := range rooms {
+		write(item, "")
+	}
+	for _, item := range links {
+		write(item, "")
+	}
+	for _, comment := range pending {
+		b.WriteString(comment + "\n")
+	}
+	return b.String(), nil
+}
+
+func formatRoom(r *room) string {
+	return r.Name + " " + strconv.Itoa(r.X) + " " + strconv.Itoa(r.Y)
+}
+
+// formatLink - returns link with sorted names of rooms: a-b (or one-way a>b), length and capacity are written only if they aren't default: a-b 3 cap=2
+func formatLink(a *anthive, line string) string {
+	link := strings.Fields(line)[0]
+	names := strings.Split(link, ">")
+	// one-way link keeps direction
+	if len(names) != 2 {
+		names = strings.Split(link, "-")
+		sort.Strings(names)
+		link = strings.Join(names, "-")
+	}
+	room1, room2 := a.Rooms[names[0]], a.Rooms[names[1]]
+	if length := room1.length(room2); length != 1 {
+		link += " " + strconv.Itoa(length)
+	}
+	if capacity, ok := room1.Capacities[room2]; ok && (capacity != 1 || a.isDirect(room1, room2)) {
+		link += " cap=" + strconv.Itoa(capacity)
+	}
+	return link
+}
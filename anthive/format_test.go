@@ -0,0 +1,74 @@
+package anthive
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "rooms and tunnels are sorted",
+			content: "3\n# hive\n##end\ne 3 0\nb 2 0\n##start\ns 0 0\ns-b\n# tunnel to end\ne-b\nb-s\n\n",
+			want:    "3\n##start\ns 0 0\n# hive\n##end\ne 3 0\nb 2 0\nb-s\n# tunnel to end\nb-e\n",
+		},
+		{
+			name:    "formatted map isn't changed",
+			content: "2\n##start\ns 0 0\n##end\ne 2 0\na 1 0\na-s\na-e\n",
+			want:    "2\n##start\ns 0 0\n##end\ne 2 0\na 1 0\na-s\na-e\n",
+		},
+		{
+			name:    "one-way tunnel with length and capacity",
+			content: "2\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns>a 2 cap=2\ne-a\n",
+			want:    "2\n##start\ns 0 0\n##end\ne 2 0\na 1 0\ns>a 2 cap=2\na-e\n",
+		},
+	}
+	for _, test := range tests {
+		formatted, err := Format(test.content)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if formatted != test.want {
+			t.Errorf("%s: formatted\n%s\nwant\n%s", test.name, formatted, test.want)
+		}
+		// fmt --check accepts the map only if formatting doesn't change it
+		again, err := Format(formatted)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if again != formatted {
+			t.Errorf("%s: formatting isn't idempotent:\n%s\nformatted again\n%s", test.name, formatted, again)
+		}
+	}
+}
+
+func TestFormatOneWayRoundTrip(t *testing.T) {
+	formatted, err := Format("3\n##start\ns 0 0\n##capacity 2\na 1 0\nb 1 2\n##end\ne 2 0\nb>e 3 cap=2\ns>a 2 cap=2\ne-a\ns-b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ReadAnthive(formatted, FORMAT_MAP)
+	if err != nil {
+		t.Fatalf("formatted map can't be read: %v\n%s", err, formatted)
+	}
+	want := map[string]MapLink{
+		"b>e": {From: "b", To: "e", OneWay: true, Length: 3, Capacity: 2},
+		"s>a": {From: "s", To: "a", OneWay: true, Length: 2, Capacity: 2},
+		"a-e": {From: "a", To: "e", Length: 1},
+		"b-s": {From: "b", To: "s", Length: 1},
+	}
+	links := a.Map().Links
+	if len(links) != len(want) {
+		t.Fatalf("%d tunnels after formatting, want %d:\n%s", len(links), len(want), formatted)
+	}
+	for _, l := range links {
+		key := l.From + "-" + l.To
+		if l.OneWay {
+			key = l.From + ">" + l.To
+		}
+		if w, ok := want[key]; !ok || l.OneWay != w.OneWay || l.Length != w.Length || l.Capacity != w.Capacity {
+			t.Errorf("tunnel %+v after formatting, want %+v", l, w)
+		}
+	}
+}
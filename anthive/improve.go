@@ -0,0 +1,173 @@
+package anthive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Local search after the solver (classic maps): one or two paths of the result are removed and free rooms are searched
+// for up to one more paths than were removed by min cost flow (rerouting, swapping, dropping, or one long path becomes two shorter paths).
+// Change is kept if the count of turns for the count of ants is reduced, search repeats until there is no such change.
+// Ants are sent by the kept paths with the least count of turns, then with the least sum of lengths of paths
+
+// Improvement - change of paths by local search which reduces count of turns
+type Improvement struct {
+	Before, After int        // count of turns
+	Removed       [][]string // rooms of removed paths from start room to end room
+	Added         [][]string // rooms of added paths from start room to end room
+}
+
+// Rules for Improve:
+// Local search is supported only for classic maps: one start and one end room, rooms and tunnels without capacity,
+// without closures, arrivals, foraging, waypoints, given paths and objectives other than turns
+
+// validateImprove - returns error if local search isn't supported for the anthive
+func (a *anthive) validateImprove(o *Options) error {
+	if len(o.Waypoints) > 0 {
+		return errors.New("local search can't be used with waypoints")
+	} else if o.pinned != nil {
+		return errors.New("local search can't be used with given paths")
+	} else if len(a.Colonies) > 0 || a.Foraging || a.Timed || a.Arrivals != nil || a.needsNetwork() {
+		return errors.New("local search is supported only for classic maps")
+	} else if o.Objective != "" && o.Objective != OBJECTIVE_TURNS {
+		return fmt.Errorf("local search can't be used with objective '%v'", o.Objective)
+	}
+	return nil
+}
+
+// improve - changes paths of the result while some change reduces count of turns, improvements are saved in the result
+func (a *anthive) improve(o *Options) {
+	start := a.Rooms[a.Start]
+	var paths []*list
+	for _, path := range a.Result.Paths {
+		copied := *path
+		copied.Start, copied.Departures = start, nil
+		paths = append(paths, &copied)
+	}
+	if len(paths) == 0 || hasDirectPath(paths) {
+		return
+	}
+	_, costs := planObjective(OBJECTIVE_TURNS_MOVES, a.AntsCount, paths)
+	steps := a.StepsCount
+	changed := costs.Turns < steps
+	if changed {
+		// ants are distributed better by the same paths
+		a.Result.Improvements = append(a.Result.Improvements, Improvement{Before: steps, After: costs.Turns})
+		steps = costs.Turns
+	}
+	for {
+		next, nextSteps, removed, added := a.changePaths(o, paths, steps)
+		if next == nil {
+			break
+		}
+		// paths which are removed and found again aren't changed
+		a.Result.Improvements = append(a.Result.Improvements, Improvement{Before: steps, After: nextSteps,
+			Removed: otherRoutes(routesOf(removed), routesOf(added)), Added: otherRoutes(routesOf(added), routesOf(removed))})
+		paths, steps, changed = next, nextSteps, true
+	}
+	if changed {
+		a.Result.Paths, costs = planObjective(OBJECTIVE_TURNS_MOVES, a.AntsCount, paths)
+		a.StepsCount = costs.Turns
+	}
+}
+
+// changePaths - returns the first change of paths which reduces count of turns: new paths, count of turns, removed and added paths.
+// Returns nil paths if there is no such change
+func (a *anthive) changePaths(o *Options, paths []*list, steps int) ([]*list, int, []*list, []*list) {
+	var removals [][]int
+	for i := range paths {
+		removals = append(removals, []int{i})
+	}
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			removals = append(removals, []int{i, j})
+		}
+	}
+	for _, removal := range removals {
+		var kept, removed []*list
+		used := &usage{Rooms: make(map[*room]int), Tunnels: make(map[[2]*room]int)}
+		for i, path := range paths {
+			if i == removal[0] || i == removal[len(removal)-1] {
+				removed = append(removed, path)
+				continue
+			}
+			kept = append(kept, path)
+			used.add(path)
+		}
+		n, source, sink := a.buildNetwork(o, nil, used)
+		for count := 0; count <= len(removal)+1; count++ {
+			if count > 0 && !n.augment(source, sink) {
+				break
+			}
+			added := n.paths(a)
+			candidate := append(append([]*list{}, kept...), added...)
+			if len(candidate) == 0 || samePaths(removed, added) {
+				continue
+			}
+			sort.SliceStable(candidate, func(i, j int) bool { return candidate[i].Dist < candidate[j].Dist })
+			if _, costs := planObjective(OBJECTIVE_TURNS_MOVES, a.AntsCount, candidate); costs.Turns < steps {
+				return candidate, costs.Turns, removed, added
+			}
+		}
+	}
+	return nil, 0, nil, nil
+}
+
+// samePaths - returns true if paths go through the same rooms in any order
+func samePaths(paths, other []*list) bool {
+	return len(paths) == len(other) && len(otherRoutes(routesOf(paths), routesOf(other))) == 0
+}
+
+// otherRoutes - returns routes which aren't in other routes
+func otherRoutes(routes, other [][]string) [][]string {
+	found := make(map[string]bool)
+	for _, route := range other {
+		found[strings.Join(route, "-")] = true
+	}
+	var result [][]string
+	for _, route := range routes {
+		if !found[strings.Join(route, "-")] {
+			result = append(result, route)
+		}
+	}
+	return result
+}
+
+// routesOf - returns rooms of paths from start room to end room
+func routesOf(paths []*list) [][]string {
+	result := make([][]string, len(paths))
+	for i, path := range paths {
+		result[i] = []string{path.Start.Name}
+		for node := path.Front; node != nil; node = node.Next {
+			result[i] = append(result[i], node.Room.Name)
+		}
+	}
+	return result
+}
+
+// WriteImprovements - writes improvements of local search, one line for every improvement
+func (r *Result) WriteImprovements(w io.Writer) error {
+	for i, improvement := range r.Improvements {
+		_, err := fmt.Fprintf(w, "improvement %d: %d -> %d turns, removed: %s, added: %s\n", i+1, improvement.Before, improvement.After,
+			joinRoutes(improvement.Removed), joinRoutes(improvement.Added))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinRoutes - returns routes separated by spaces, "none" if there are no routes
+func joinRoutes(routes [][]string) string {
+	if len(routes) == 0 {
+		return "none"
+	}
+	result := make([]string, len(routes))
+	for i, route := range routes {
+		result[i] = strings.Join(route, "-")
+	}
+	return strings.Join(result, " ")
+}
@@ -0,0 +1,71 @@
+package anthive
+
+import "testing"
+
+func TestImprove(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   [][]string // weak result of the solver, nil is result of the classic solver
+		before  int
+		turns   int
+		removed string // rooms of paths removed by the first improvement
+		added   string // rooms of paths added by the first improvement
+	}{
+		{"long path is rerouted", [][]string{{"1", "2", "7", "6", "cool"}}, 6, 4, "1-2-7-6-cool", "1-3-4-cool"},
+		{"paths are swapped", [][]string{{"1", "2", "7", "4", "cool"}, {"1", "3", "5", "6", "cool"}}, 5, 4, "", ""},
+		{"result of the classic solver is kept", nil, 4, 4, "", ""},
+	}
+	for _, test := range tests {
+		// paths of the weak result are given to the solver, then local search changes them
+		a, _ := solveTest(t, test.name, exampleMap, &Options{Paths: test.paths}, test.before)
+		a.improve(&Options{})
+		moves := a.Result.Moves()
+		if err := a.Result.Map.Verify(moves); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if len(moves) != test.turns {
+			t.Errorf("%s: %d turns, want %d", test.name, len(moves), test.turns)
+		}
+		// every improvement reduces count of turns of the previous one
+		steps := test.before
+		for _, improvement := range a.Result.Improvements {
+			if improvement.Before != steps || improvement.After >= steps {
+				t.Errorf("%s: improvement %d -> %d after %d turns", test.name, improvement.Before, improvement.After, steps)
+			}
+			steps = improvement.After
+		}
+		if steps != test.turns {
+			t.Errorf("%s: improvements end with %d turns, want %d", test.name, steps, test.turns)
+		}
+		if test.removed == "" {
+			continue
+		}
+		first := a.Result.Improvements[0]
+		if removed, added := joinRoutes(first.Removed), joinRoutes(first.Added); removed != test.removed || added != test.added {
+			t.Errorf("%s: removed %s, added %s, want removed %s, added %s", test.name, removed, added, test.removed, test.added)
+		}
+	}
+}
+
+func TestValidateImprove(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		options Options
+		err     string
+	}{
+		{"classic map", exampleMap, Options{Improve: true}, ""},
+		{"given paths", exampleMap, Options{Improve: true, Paths: [][]string{{"1", "3", "4", "cool"}}, Fill: true}, "local search can't be used with given paths"},
+		{"waypoints", exampleMap, Options{Improve: true, Waypoints: []string{"4"}}, "local search can't be used with waypoints"},
+		{"objective", exampleMap, Options{Improve: true, Objective: OBJECTIVE_MOVES}, "local search can't be used with objective 'moves'"},
+		{"wide room", "2\n##start\ns 0 0\n##capacity 2\na 1 0\n##end\ne 2 0\ns-a\na-e\n", Options{Improve: true}, "local search is supported only for classic maps"},
+	}
+	for _, test := range tests {
+		a := readTest(t, test.name, test.content)
+		o := test.options
+		err := a.SetOptions(&o)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: error %v, want '%v'", test.name, err, test.err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package anthive
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -13,7 +14,15 @@ import (
 // SetAntsFromLine - set data about count ants from line to anthive
 func (a *anthive) SetAntsFromLine(line string) error {
 	countAnts, err := strconv.Atoi(line)
-	if err != nil || countAnts < 1 {
+	if err != nil {
+		return errors.New("invalid number of Ants")
+	}
+	return a.SetAnts(countAnts)
+}
+
+// SetAnts - set count of ants to anthive
+func (a *anthive) SetAnts(countAnts int) error {
+	if countAnts < 1 {
 		return errors.New("invalid number of Ants")
 	}
 	a.AntsCount = countAnts
@@ -24,26 +33,62 @@ func (a *anthive) SetAntsFromLine(line string) error {
 // Rules for Room:
 // Names must be unique
 // Coordinates must be unique
+// Names can't start with '#' and coordinates can't be negative if Rules don't allow it
 
 // SetRoomFromLine - insert rooms into anthive, returns error if invalid room
 func (a *anthive) SetRoomFromLine(line string) (*room, error) {
 	splited := strings.Split(line, " ")
-	if len(splited) != 3 || len(splited[0]) < 1 {
+	if len(splited) != 3 {
 		return nil, errors.New("invalid format of room")
-	} else if strings.HasPrefix(splited[0], "L") {
-		return nil, errors.New("room name can't be started with 'L'")
-	} else if strings.Contains(splited[0], "-") {
-		return nil, errors.New("room name can't have '-'")
-	} else if _, ok := a.Rooms[splited[0]]; ok {
-		return nil, fmt.Errorf("room name duplicated: '%v'", splited[0])
 	}
-
 	name := splited[0]
+	if err := a.validateRoomName(name); err != nil {
+		return nil, err
+	}
 	x, errX := strconv.Atoi(splited[1])
 	y, errY := strconv.Atoi(splited[2])
 	if errX != nil || errY != nil {
 		return nil, errors.New("room coords can only be numbers")
-	} else if _, ok := a.FieldInfo.UsingCoordinates[x]; ok {
+	}
+	return a.addRoom(name, x, y)
+}
+
+// AddRoom - insert room into anthive, returns error if invalid room
+func (a *anthive) AddRoom(name string, x, y int) (*room, error) {
+	if err := a.validateRoomName(name); err != nil {
+		return nil, err
+	}
+	return a.addRoom(name, x, y)
+}
+
+func (a *anthive) validateRoomName(name string) error {
+	if len(name) < 1 {
+		return errors.New("invalid format of room")
+	} else if strings.HasPrefix(name, "L") {
+		return errors.New("room name can't be started with 'L'")
+	} else if strings.Contains(name, "-") {
+		return errors.New("room name can't have '-'")
+	} else if strings.Contains(name, ">") {
+		return errors.New("room name can't have '>'")
+	} else if strings.ContainsAny(name, " \t") {
+		return errors.New("room name can't have spaces")
+	} else if strings.HasPrefix(name, "##") || strings.HasPrefix(name, "#") && !a.Rules.HashNames {
+		return errors.New("room name can't be started with '#'")
+	} else if _, ok := a.Rooms[name]; ok {
+		return fmt.Errorf("room name duplicated: '%v'", name)
+	}
+	return nil
+}
+
+// addRoom - insert room with valid name, checks coordinates
+func (a *anthive) addRoom(name string, x, y int) (*room, error) {
+	if (x < 0 || y < 0) && !a.Rules.NegativeCoords {
+		return nil, fmt.Errorf("room coords can't be negative; room name: '%v'", name)
+	}
+	if a.FieldInfo.UsingCoordinates == nil {
+		a.FieldInfo.UsingCoordinates = make(map[int]map[int]bool)
+	}
+	if _, ok := a.FieldInfo.UsingCoordinates[x]; ok {
 		if a.FieldInfo.UsingCoordinates[x][y] {
 			return nil, fmt.Errorf("room coords must be unique; room name: '%v'", name)
 		}
@@ -53,13 +98,15 @@ func (a *anthive) SetRoomFromLine(line string) (*room, error) {
 	a.FieldInfo.UsingCoordinates[x][y] = true
 
 	room := &room{
-		Name:   name,
-		X:      x,
-		Y:      y,
-		Paths:  make(map[*room]int),
-		Weight: [2]int{0, 0},
+		Name:     name,
+		X:        x,
+		Y:        y,
+		Capacity: 1,
+		Paths:    make(map[*room]int),
+		Weight:   [2]int{0, 0},
 	}
 	a.Rooms[name] = room
+	a.RoomsOrder = append(a.RoomsOrder, room)
 	return room, nil
 }
 
@@ -69,40 +116,668 @@ func (a *anthive) SetMainRooms(line string, startOrEnd bool) error {
 	if err != nil {
 		return err
 	}
+	a.setMainRoom(room, startOrEnd)
+	return nil
+}
+
+// AddMainRoom - insert room into anthive and set Start or End by marker startOrEnd
+func (a *anthive) AddMainRoom(name string, x, y int, startOrEnd bool) error {
+	room, err := a.AddRoom(name, x, y)
+	if err != nil {
+		return err
+	}
+	a.setMainRoom(room, startOrEnd)
+	return nil
+}
+
+// setMainRoom - adds start or end room, the first of them is Start or End
+func (a *anthive) setMainRoom(room *room, startOrEnd bool) {
 	if startOrEnd {
-		a.Start = room.Name
+		if !a.FieldInfo.Start {
+			a.Start = room.Name
+		}
+		a.Starts = append(a.Starts, room.Name)
+		a.FieldInfo.Start = true
 	} else {
-		a.End = room.Name
+		if !a.FieldInfo.End {
+			a.End = room.Name
+		}
+		a.Ends = append(a.Ends, room.Name)
+		a.FieldInfo.End = true
+	}
+}
+
+// Rules for Start and End rooms:
+// Map can have several start and end rooms, ants go from any start room to any end room (if map hasn't colonies)
+// Start room can have count of ants: ##start N, then exactly N ants go from it.
+// Other ants go from start rooms without count, so if all start rooms have count then sum of them must be count of ants
+
+// SetStartAnts - sets count of ants which go from the start room
+func (a *anthive) SetStartAnts(name string, ants int) error {
+	if !a.isStart(name) {
+		return fmt.Errorf("room '%v' isn't start room", name)
+	} else if ants < 1 {
+		return errors.New("count of ants of start room must be > 0")
+	}
+	if a.StartAnts == nil {
+		a.StartAnts = make(map[string]int)
+	}
+	a.StartAnts[name] = ants
+	return nil
+}
+
+// validateStartAnts - checks counts of ants of start rooms
+func (a *anthive) validateStartAnts() error {
+	if len(a.StartAnts) == 0 {
+		return nil
+	}
+	sum := 0
+	for _, ants := range a.StartAnts {
+		sum += ants
+	}
+	if sum > a.AntsCount {
+		return fmt.Errorf("start rooms have %d ants, but there are only %d ants", sum, a.AntsCount)
+	} else if sum < a.AntsCount && len(a.StartAnts) == len(a.Starts) {
+		return fmt.Errorf("start rooms have %d ants, but there are %d ants", sum, a.AntsCount)
+	}
+	return nil
+}
+
+// freeAnts - returns count of ants which go from start rooms without count
+func (a *anthive) freeAnts() int {
+	ants := a.AntsCount
+	for _, count := range a.StartAnts {
+		ants -= count
+	}
+	return ants
+}
+
+// Rules for Colonies:
+// ##colony name N declares colony with N ants, ##start name and ##end name mark its start and end rooms.
+// Colony has one start room and one end room, ants of colony go only to its end room.
+// If map has colonies, then every start and end room belongs to some colony and sum of ants of colonies is count of ants.
+// Name of colony can't be a number and can't have '-', '.' or spaces
+
+// AddColonyFromLine - reads colony from ##colony command
+func (a *anthive) AddColonyFromLine(line string) error {
+	splited := strings.Split(line, " ")
+	if len(splited) != 3 {
+		return fmt.Errorf("invalid format of colony. Line: '%v'", line)
+	}
+	ants, err := strconv.Atoi(splited[2])
+	if err != nil {
+		return fmt.Errorf("invalid count of ants of colony. Line: '%v'", line)
+	}
+	if err := a.AddColony(splited[1], ants); err != nil {
+		return fmt.Errorf("%v. Line: '%v'", err, line)
+	}
+	return nil
+}
+
+// AddColony - sets count of ants of colony. Colony can be already created by its room
+func (a *anthive) AddColony(name string, ants int) error {
+	if ants < 1 {
+		return errors.New("count of ants of colony must be > 0")
+	}
+	c, err := a.colony(name)
+	if err != nil {
+		return err
+	} else if c.Ants != 0 {
+		return fmt.Errorf("colony duplicated: '%v'", name)
+	}
+	c.Ants = ants
+	return nil
+}
+
+// SetColony - makes start or end room the room of colony
+func (a *anthive) SetColony(roomName, name string) error {
+	c, err := a.colony(name)
+	if err != nil {
+		return err
+	}
+	if a.isStart(roomName) {
+		if c.Start != "" {
+			return fmt.Errorf("colony '%v' already has start room", name)
+		}
+		c.Start = roomName
+	} else if a.isEnd(roomName) {
+		if c.End != "" {
+			return fmt.Errorf("colony '%v' already has end room", name)
+		}
+		c.End = roomName
+	} else {
+		return fmt.Errorf("room '%v' isn't start or end room", roomName)
+	}
+	return nil
+}
+
+// colony - returns colony by name, colony is added if it isn't found
+func (a *anthive) colony(name string) (*colony, error) {
+	for _, c := range a.Colonies {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	if _, err := strconv.Atoi(name); err == nil || name == "" || strings.ContainsAny(name, "-. ") {
+		return nil, fmt.Errorf("invalid name of colony: '%v'", name)
+	}
+	c := &colony{Name: name}
+	a.Colonies = append(a.Colonies, c)
+	return c, nil
+}
+
+// validateColonies - checks colonies, then ants of every colony are set as count of ants of its start room
+func (a *anthive) validateColonies() error {
+	if len(a.Colonies) == 0 {
+		return nil
+	}
+	sum := 0
+	rooms := make(map[string]bool)
+	for _, c := range a.Colonies {
+		if c.Ants == 0 {
+			return fmt.Errorf("colony '%v' hasn't count of ants", c.Name)
+		} else if c.Start == "" {
+			return fmt.Errorf("colony '%v' hasn't start room", c.Name)
+		} else if c.End == "" {
+			return fmt.Errorf("colony '%v' hasn't end room", c.Name)
+		}
+		sum += c.Ants
+		rooms[c.Start], rooms[c.End] = true, true
+	}
+	for _, name := range append(append([]string{}, a.Starts...), a.Ends...) {
+		if !rooms[name] {
+			return fmt.Errorf("room '%v' doesn't belong to colony", name)
+		}
+	}
+	if sum != a.AntsCount {
+		return fmt.Errorf("colonies have %d ants, but there are %d ants", sum, a.AntsCount)
+	}
+	for _, c := range a.Colonies {
+		if err := a.SetStartAnts(c.Start, c.Ants); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStart, isEnd - returns true if room is one of start or end rooms
+func (a *anthive) isStart(name string) bool {
+	for _, start := range a.Starts {
+		if start == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *anthive) isEnd(name string) bool {
+	for _, end := range a.Ends {
+		if end == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules for Capacity:
+// ##capacity N is written before the room line, N must be > 0
+// Start and End rooms can have any count of ants, so they can't have capacity
+
+// SetCapacityFromLine - reads capacity of the next room from ##capacity command
+func (a *anthive) SetCapacityFromLine(line string) error {
+	splited := strings.Split(line, " ")
+	if len(splited) != 2 {
+		return fmt.Errorf("invalid format of capacity. Line: '%v'", line)
+	}
+	capacity, err := strconv.Atoi(splited[1])
+	if err != nil || capacity < 1 {
+		return fmt.Errorf("invalid capacity. Line: '%v'", line)
+	}
+	a.FieldInfo.Capacity = capacity
+	return nil
+}
+
+// SetCapacity - sets count of ants which room can have at the same time
+func (a *anthive) SetCapacity(name string, capacity int) error {
+	room := a.Rooms[name]
+	if room == nil {
+		return fmt.Errorf("unknown room '%v'", name)
+	} else if capacity < 1 {
+		return errors.New("capacity of room must be > 0")
+	} else if a.isStart(name) || a.isEnd(name) {
+		return errors.New("start and end rooms can't have capacity")
+	}
+	room.Capacity = capacity
+	if capacity > 1 {
+		a.Wide = true
+	}
+	return nil
+}
+
+// Rules for Closures:
+// ##closed 5-9 12 is written before the room or path line: room or path is closed on turns 5..9 and 12 (turns start from 1)
+// Several ##closed commands add their turns. Start and End rooms can't be closed
+// Ant can't be in closed room on its turns. Ant which passes path with length k is inside it k turns
+// (from leaving of previous room to arrival), and closed path can't have ants on its turns
+
+// SetClosedFromLine - reads turns when the next room or path is closed from ##closed command
+func (a *anthive) SetClosedFromLine(line string) error {
+	turns, err := ParseTurns(strings.TrimPrefix(line, "##closed "))
+	if err != nil {
+		return fmt.Errorf("%v. Line: '%v'", err, line)
+	}
+	a.FieldInfo.Closed = append(a.FieldInfo.Closed, turns...)
+	return nil
+}
+
+// ParseTurns - parses turns separated by spaces: 5-9 12
+func ParseTurns(value string) ([]Turns, error) {
+	var result []Turns
+	for _, field := range strings.Fields(value) {
+		bounds := strings.SplitN(field, "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		to := from
+		if err == nil && len(bounds) == 2 {
+			to, err = strconv.Atoi(bounds[1])
+		}
+		if err != nil || from < 1 || to < from {
+			return nil, fmt.Errorf("invalid turns '%v'", field)
+		}
+		result = append(result, Turns{From: from, To: to})
+	}
+	if result == nil {
+		return nil, errors.New("turns of closure are missed")
+	}
+	return result, nil
+}
+
+// setClosed - closes the room (name2 is empty) or path on turns from ##closed commands
+func (a *anthive) setClosed(name1, name2 string) error {
+	turns := a.FieldInfo.Closed
+	a.FieldInfo.Closed = nil
+	for _, t := range turns {
+		var err error
+		if name2 == "" {
+			err = a.SetRoomClosed(name1, t.From, t.To)
+		} else {
+			err = a.SetLinkClosed(name1, name2, t.From, t.To)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setClosedTurns - closes the room (name2 is empty) or path on turns written as 5-9 12
+func (a *anthive) setClosedTurns(name1, name2, value string) error {
+	turns, err := ParseTurns(value)
+	if err != nil {
+		return err
+	}
+	a.FieldInfo.Closed = turns
+	return a.setClosed(name1, name2)
+}
+
+// SetRoomClosed - closes the room on turns from..to
+func (a *anthive) SetRoomClosed(name string, from, to int) error {
+	room := a.Rooms[name]
+	if room == nil {
+		return fmt.Errorf("unknown room '%v'", name)
+	} else if from < 1 || to < from {
+		return errors.New("invalid turns of closure")
+	} else if a.isStart(name) || a.isEnd(name) {
+		return errors.New("start and end rooms can't be closed")
+	}
+	room.Closures = append(room.Closures, Turns{From: from, To: to})
+	a.Timed = true
+	return nil
+}
+
+// SetLinkClosed - closes the path between rooms on turns from..to
+func (a *anthive) SetLinkClosed(name1, name2 string, from, to int) error {
+	room1 := a.Rooms[name1]
+	room2 := a.Rooms[name2]
+	if room1 == nil || room2 == nil {
+		return errors.New("path contains unknown room")
+	} else if _, ok := room1.Paths[room2]; !ok {
+		return errors.New("rooms aren't linked")
+	} else if from < 1 || to < from {
+		return errors.New("invalid turns of closure")
+	}
+	if room1.TunnelClosures == nil {
+		room1.TunnelClosures = make(map[*room][]Turns)
+	}
+	if room2.TunnelClosures == nil {
+		room2.TunnelClosures = make(map[*room][]Turns)
+	}
+	room1.TunnelClosures[room2] = append(room1.TunnelClosures[room2], Turns{From: from, To: to})
+	room2.TunnelClosures[room1] = append(room2.TunnelClosures[room1], Turns{From: from, To: to})
+	a.Timed = true
+	return nil
+}
+
+// Rules for Arrivals:
+// ##arrivals 1:3 4:2 is written in rooms part: 3 ants come into start room on turn 1 and 2 ants on turn 4 (turns start from 1).
+// ##arrivals rate=2 - 2 ants come every turn from turn 1, ##arrivals rate=2/3 - 2 ants every 3 turns, the last turn can have less ants.
+// Sum of ants of arrivals is count of ants. Ants come in order of their numbers and can't leave start room before their turn.
+// Arrivals can't be used with colonies and counts of ants of start rooms
+
+// SetArrivalsFromLine - reads arrivals of ants from ##arrivals command
+func (a *anthive) SetArrivalsFromLine(line string) error {
+	if err := a.SetArrivals(strings.TrimPrefix(line, "##arrivals ")); err != nil {
+		return fmt.Errorf("%v. Line: '%v'", err, line)
+	}
+	return nil
+}
+
+// SetArrivals - sets arrivals of ants written as 1:3 4:2 or rate=N/K
+func (a *anthive) SetArrivals(value string) error {
+	if a.Arrivals != nil {
+		return errors.New("arrivals duplicated")
+	}
+	arrivals, err := ParseArrivals(value, a.AntsCount)
+	if err != nil {
+		return err
+	}
+	a.Arrivals = arrivals
+	return nil
+}
+
+// ParseArrivals - parses arrivals of ants: turn:count pairs separated by spaces (1:3 4:2) or rate=N/K.
+// Returns arrivals sorted by turns, ants of the same turn are summed
+func ParseArrivals(value string, ants int) ([]Arrival, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "rate=") {
+		return parseRate(strings.TrimPrefix(value, "rate="), ants)
+	}
+	counts := make(map[int]int)
+	sum := 0
+	for _, field := range strings.Fields(value) {
+		pair := strings.SplitN(field, ":", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid arrival '%v'", field)
+		}
+		turn, err := strconv.Atoi(pair[0])
+		count, err2 := strconv.Atoi(pair[1])
+		if err != nil || err2 != nil || turn < 1 || count < 1 {
+			return nil, fmt.Errorf("invalid arrival '%v'", field)
+		}
+		counts[turn] += count
+		sum += count
+	}
+	if len(counts) == 0 {
+		return nil, errors.New("arrivals are missed")
+	} else if sum != ants {
+		return nil, fmt.Errorf("arrivals have %d ants, but there are %d ants", sum, ants)
+	}
+	var result []Arrival
+	for turn, count := range counts {
+		result = append(result, Arrival{Turn: turn, Ants: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Turn < result[j].Turn })
+	return result, nil
+}
+
+// parseRate - returns arrivals of N ants every K turns from turn 1 written as N/K (N if K is 1)
+func parseRate(value string, ants int) ([]Arrival, error) {
+	parts := strings.SplitN(value, "/", 2)
+	rate, err := strconv.Atoi(parts[0])
+	every := 1
+	if err == nil && len(parts) == 2 {
+		every, err = strconv.Atoi(parts[1])
+	}
+	if err != nil || rate < 1 || every < 1 {
+		return nil, fmt.Errorf("invalid rate '%v'", value)
+	}
+	var result []Arrival
+	for turn := 1; ants > 0; turn += every {
+		count := rate
+		if count > ants {
+			count = ants
+		}
+		result = append(result, Arrival{Turn: turn, Ants: count})
+		ants -= count
+	}
+	return result, nil
+}
+
+// validateArrivals - checks that arrivals are used without colonies and counts of ants of start rooms
+func (a *anthive) validateArrivals() error {
+	if a.Arrivals == nil {
+		return nil
+	} else if len(a.Colonies) > 0 {
+		return errors.New("arrivals can't be used with colonies")
+	} else if len(a.StartAnts) > 0 {
+		return errors.New("arrivals can't be used with counts of ants of start rooms")
+	}
+	return nil
+}
+
+// Rules for Foraging:
+// ##foraging is written in rooms part: every ant goes from start room to one of end rooms and back to one of start rooms.
+// Ants of both ways share rooms and tunnels, ant can't go through start and end rooms and it can wait in end room.
+// Moves of the way back are marked: L1<-a. Foraging can't be used with colonies
+
+// SetForaging - makes ants go to end room and back to start room
+func (a *anthive) SetForaging() error {
+	if a.Foraging {
+		return errors.New("##foraging duplicated")
+	}
+	a.Foraging = true
+	return nil
+}
+
+// validateForaging - checks that foraging is used without colonies
+func (a *anthive) validateForaging() error {
+	if a.Foraging && len(a.Colonies) > 0 {
+		return errors.New("foraging can't be used with colonies")
 	}
 	return nil
 }
 
 // Rules for Room Relations
 // Room cant has path to themseld
+// Length of path is optional: a-b 3, it must be > 0
+// Capacity of path (ants per turn) is optional: a-b cap=2 or a-b 3 cap=2, it must be > 0.
+// By default path has capacity 1, path between start and end hasn't limit
+// One-way path is written as a>b, ants can go only from a to b
 
 // SetPathsFromLine - builds relationships between rooms available in anthive by line;
 func (a *anthive) SetPathsFromLine(line string) error {
-	splited := strings.Split(line, "-")
-	if len(splited) != 2 || len(splited[0]) < 1 || len(splited[1]) < 1 {
+	fields := strings.Split(line, " ")
+	length, capacity := 1, 0
+	for i, field := range fields[1:] {
+		var err error
+		if strings.HasPrefix(field, "cap=") && capacity == 0 {
+			capacity, err = strconv.Atoi(strings.TrimPrefix(field, "cap="))
+			if err != nil || capacity < 1 {
+				return fmt.Errorf("invalid capacity of path. Line: '%v'", line)
+			}
+		} else if i == 0 {
+			length, err = strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("invalid length of path. Line: '%v'", line)
+			}
+		} else {
+			return errors.New("invalid format of path")
+		}
+	}
+	oneWay := strings.Contains(fields[0], ">")
+	splited := strings.Split(fields[0], "-")
+	if oneWay {
+		splited = strings.Split(fields[0], ">")
+	}
+	if len(splited) != 2 || len(splited[0]) < 1 || len(splited[1]) < 1 || oneWay && strings.Contains(fields[0], "-") {
 		return errors.New("invalid format of path")
 	}
-	name1, name2 := splited[0], splited[1]
+	var err error
+	if oneWay {
+		err = a.AddOneWayLink(splited[0], splited[1], length)
+	} else {
+		err = a.AddLink(splited[0], splited[1], length)
+	}
+	if err != nil {
+		return fmt.Errorf("%v. Line: '%v'", err, line)
+	}
+	if capacity != 0 {
+		if err := a.SetLinkCapacity(splited[0], splited[1], capacity); err != nil {
+			return fmt.Errorf("%v. Line: '%v'", err, line)
+		}
+	}
+	if err := a.setClosed(splited[0], splited[1]); err != nil {
+		return fmt.Errorf("%v. Line: '%v'", err, line)
+	}
+	return nil
+}
+
+// AddLink - builds relationship with length between rooms available in anthive
+func (a *anthive) AddLink(name1, name2 string, length int) error {
+	return a.addLink(name1, name2, length, false)
+}
+
+// AddOneWayLink - builds relationship with length, ants can go only from room with name1 to room with name2
+func (a *anthive) AddOneWayLink(from, to string, length int) error {
+	return a.addLink(from, to, length, true)
+}
+
+func (a *anthive) addLink(name1, name2 string, length int, oneWay bool) error {
 	if name1 == name2 {
-		return fmt.Errorf("rooms can't link themselves. Line: '%v'", line)
+		return errors.New("rooms can't link themselves")
+	} else if length < 1 {
+		return errors.New("length of path must be > 0")
 	}
 	room1 := a.Rooms[name1]
 	room2 := a.Rooms[name2]
 	if room1 == nil || room2 == nil {
-		return fmt.Errorf("path contains unknown room. Line: '%v'", line)
+		return errors.New("path contains unknown room")
+	}
+	if _, ok := room1.Paths[room2]; ok && !a.Rules.DuplicateLinks {
+		return errors.New("rooms already linked")
+	} else if !ok {
+		a.Links = append(a.Links, [2]*room{room1, room2})
+	} else if room1.length(room2) != length {
+		return errors.New("rooms already linked with another length")
+	} else if room1.Closed[room2] || room2.Closed[room1] != oneWay {
+		return errors.New("rooms already linked with another direction")
 	}
-	// if _, ok := room1.Paths[room2]; ok {
-	// 	return fmt.Errorf("rooms already linked. Line: '%v'", line)
-	// }
 	room1.Paths[room2] = STABLE
 	room2.Paths[room1] = STABLE
+	if oneWay {
+		a.Directed = true
+		if room2.Closed == nil {
+			room2.Closed = make(map[*room]bool)
+		}
+		room2.Closed[room1] = true
+		room2.Paths[room1] = CLOSED
+	}
+	if length != 1 {
+		a.Weighted = true
+		room1.setLength(room2, length)
+		room2.setLength(room1, length)
+	}
+	return nil
+}
+
+// canGo - returns true if ants can go by path from room to next room
+func (r *room) canGo(next *room) bool {
+	_, ok := r.Paths[next]
+	return ok && !r.Closed[next]
+}
+
+// length - returns length of path to next room
+func (r *room) length(next *room) int {
+	if length, ok := r.Lengths[next]; ok {
+		return length
+	}
+	return 1
+}
+
+// SetLinkCapacity - sets count of ants which can go into the path each turn
+func (a *anthive) SetLinkCapacity(name1, name2 string, capacity int) error {
+	room1 := a.Rooms[name1]
+	room2 := a.Rooms[name2]
+	if room1 == nil || room2 == nil {
+		return errors.New("path contains unknown room")
+	} else if _, ok := room1.Paths[room2]; !ok {
+		return errors.New("rooms aren't linked")
+	} else if capacity < 1 {
+		return errors.New("capacity of path must be > 0")
+	} else if prev, ok := room1.Capacities[room2]; ok && prev != capacity {
+		return errors.New("rooms already linked with another capacity")
+	}
+	if room1.Capacities == nil {
+		room1.Capacities = make(map[*room]int)
+	}
+	if room2.Capacities == nil {
+		room2.Capacities = make(map[*room]int)
+	}
+	room1.Capacities[room2] = capacity
+	room2.Capacities[room1] = capacity
+	if a.isDirect(room1, room2) || capacity != 1 {
+		a.Galleries = true
+	}
 	return nil
 }
 
+// linkCapacity - returns count of ants which can go into the path each turn, AntsCount if path hasn't limit
+func (a *anthive) linkCapacity(room1, room2 *room) int {
+	if capacity, ok := room1.Capacities[room2]; ok {
+		return capacity
+	} else if a.isDirect(room1, room2) {
+		return a.AntsCount
+	}
+	return 1
+}
+
+// isDirect - returns true if rooms are start and end
+func (a *anthive) isDirect(room1, room2 *room) bool {
+	return a.isStart(room1.Name) && a.isEnd(room2.Name) || a.isEnd(room1.Name) && a.isStart(room2.Name)
+}
+
+func (r *room) setLength(next *room, length int) {
+	if r.Lengths == nil {
+		r.Lengths = make(map[*room]int)
+	}
+	r.Lengths[next] = length
+}
+
+// removeLink - removes the path between rooms with its length, capacity, direction and closures
+func (a *anthive) removeLink(room1, room2 *room) {
+	for _, pair := range [][2]*room{{room1, room2}, {room2, room1}} {
+		delete(pair[0].Paths, pair[1])
+		delete(pair[0].Closed, pair[1])
+		delete(pair[0].Lengths, pair[1])
+		delete(pair[0].Capacities, pair[1])
+		delete(pair[0].TunnelClosures, pair[1])
+	}
+	for i, l := range a.Links {
+		if l[0] == room1 && l[1] == room2 || l[0] == room2 && l[1] == room1 {
+			a.Links = append(a.Links[:i], a.Links[i+1:]...)
+			break
+		}
+	}
+}
+
+// Validate - returns an error if anthive built without reading of lines isn't complete
+func (a *anthive) Validate() error {
+	if a.AntsCount < 1 {
+		return errors.New("here is no Ants")
+	} else if !a.FieldInfo.Start {
+		return errors.New("please set start room")
+	} else if !a.FieldInfo.End {
+		return errors.New("please set end room")
+	}
+	if err := a.validateArrivals(); err != nil {
+		return err
+	} else if err := a.validateForaging(); err != nil {
+		return err
+	} else if err := a.validateColonies(); err != nil {
+		return err
+	}
+	return a.validateStartAnts()
+}
+
 ///////////////////////////////
 /////// pushing into front of list/////////////////////////////
 func (l *list) PushFront(r *room) {
@@ -0,0 +1,165 @@
+package anthive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Rules for JSON map:
+// {"ants": 3, "rooms": [{"name": "a", "x": 0, "y": 0, "role": "start"}, ...], "tunnels": [{"from": "a", "to": "b"}, ...]}
+// Role of room is "start", "end" or empty, there can be several start and end rooms
+// Start room can have count of ants: {"name": "a", "x": 0, "y": 0, "role": "start", "ants": 2}
+// Start and end rooms of colony have its name, count of ants of colony is set in start room:
+// {"name": "a", "x": 0, "y": 0, "role": "start", "colony": "red", "ants": 2}
+// Length of tunnel is optional: {"from": "a", "to": "b", "length": 3}
+// Capacity of tunnel (ants per turn) is optional: {"from": "a", "to": "b", "capacity": 2}
+// One-way tunnel goes from "from" to "to": {"from": "a", "to": "b", "oneway": true}
+// Capacity of room is optional: {"name": "b", "x": 1, "y": 0, "capacity": 2}
+// Room and tunnel can be closed on turns: {"name": "b", "x": 1, "y": 0, "closed": "5-9 12"}
+// Ants can come into start room on turns: {"ants": 5, "arrivals": "1:3 4:2", ...} or {"ants": 5, "arrivals": "rate=2", ...}
+// Ants go to end room and back to start room: {"ants": 5, "foraging": true, ...}
+
+// Roles of rooms in JSON map
+const (
+	ROLE_START = "start"
+	ROLE_END   = "end"
+)
+
+type jsonMap struct {
+	Ants     *int         `json:"ants"`
+	Arrivals string       `json:"arrivals"`
+	Foraging bool         `json:"foraging"`
+	Rooms    []jsonRoom   `json:"rooms"`
+	Tunnels  []jsonTunnel `json:"tunnels"`
+}
+
+type jsonRoom struct {
+	Name     string `json:"name"`
+	X        *int   `json:"x"`
+	Y        *int   `json:"y"`
+	Role     string `json:"role"`
+	Capacity *int   `json:"capacity"`
+	Ants     *int   `json:"ants"`
+	Colony   string `json:"colony"`
+	Closed   string `json:"closed"`
+}
+
+type jsonTunnel struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Length   *int   `json:"length"`
+	Capacity *int   `json:"capacity"`
+	OneWay   bool   `json:"oneway"`
+	Closed   string `json:"closed"`
+}
+
+// ReadJSON - builds anthive from content of JSON map. Errors have path of invalid element
+func ReadJSON(content string) (*anthive, error) {
+	return readJSON(content, DefaultRules)
+}
+
+// readJSON - builds anthive from content of JSON map by rules
+func readJSON(content string, rules Rules) (*anthive, error) {
+	var data jsonMap
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return nil, jsonError(content, err)
+	}
+	a := Createanthive()
+	a.Rules = rules
+	if data.Ants == nil {
+		return nil, errors.New("ants: here is no Ants")
+	} else if err := a.SetAnts(*data.Ants); err != nil {
+		return nil, fmt.Errorf("ants: %v", err)
+	}
+	if data.Arrivals != "" {
+		if err := a.SetArrivals(data.Arrivals); err != nil {
+			return nil, fmt.Errorf("arrivals: %v", err)
+		}
+	}
+	a.Foraging = data.Foraging
+	if len(data.Rooms) == 0 {
+		return nil, errors.New("rooms: here is no Rooms")
+	}
+	for i, r := range data.Rooms {
+		var err error
+		if r.X == nil || r.Y == nil {
+			err = errors.New("room coords are required")
+		} else {
+			switch r.Role {
+			case ROLE_START, ROLE_END:
+				err = a.AddMainRoom(r.Name, *r.X, *r.Y, r.Role == ROLE_START)
+			case "":
+				_, err = a.AddRoom(r.Name, *r.X, *r.Y)
+			default:
+				err = fmt.Errorf("unknown role '%v'", r.Role)
+			}
+		}
+		if err == nil && r.Capacity != nil {
+			err = a.SetCapacity(r.Name, *r.Capacity)
+		}
+		if err == nil && r.Colony != "" {
+			err = a.SetColony(r.Name, r.Colony)
+		}
+		if err == nil && r.Ants != nil && r.Colony != "" && r.Role == ROLE_START {
+			err = a.AddColony(r.Colony, *r.Ants)
+		} else if err == nil && r.Ants != nil {
+			err = a.SetStartAnts(r.Name, *r.Ants)
+		}
+		if err == nil && r.Closed != "" {
+			err = a.setClosedTurns(r.Name, "", r.Closed)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rooms[%d] '%v': %v", i, r.Name, err)
+		}
+	}
+	for i, t := range data.Tunnels {
+		length := 1
+		if t.Length != nil {
+			length = *t.Length
+		}
+		var err error
+		if t.OneWay {
+			err = a.AddOneWayLink(t.From, t.To, length)
+		} else {
+			err = a.AddLink(t.From, t.To, length)
+		}
+		if err == nil && t.Capacity != nil {
+			err = a.SetLinkCapacity(t.From, t.To, *t.Capacity)
+		}
+		if err == nil && t.Closed != "" {
+			err = a.setClosedTurns(t.From, t.To, t.Closed)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tunnels[%d] '%v-%v': %v", i, t.From, t.To, err)
+		}
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// jsonError - adds line and column of invalid JSON into error
+func jsonError(content string, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		if e.Field != "" {
+			return fmt.Errorf("%v: invalid value %v, expected %v", e.Field, e.Value, e.Type)
+		}
+		offset = e.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	before := content[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len(before) - strings.LastIndex(before, "\n")
+	return fmt.Errorf("invalid JSON at line %d, column %d: %v", line, column, err)
+}
@@ -2,20 +2,30 @@ package anthive
 
 // Search shortest path using Bellman-Ford's algorithm logic and / Suurballe`s algorithm .
 
-func searchShortPath(terrain *anthive) bool {
+func searchShortPath(terrain *anthive, o *Options) bool {
 	usableRoomsQueue := &sortedQueue{}
 	startRoom := terrain.Rooms[terrain.Start]
 	endRoom := terrain.Rooms[terrain.End]
 	startRoom.VisitIn, startRoom.VisitOut = true, true
 	usableRoomsQueue.Enqueue(startRoom, 0, true)
-	for usableRoomsQueue.Front != nil && !(endRoom.VisitIn || endRoom.VisitOut) {
+	for usableRoomsQueue.Front != nil && !isReached(endRoom, usableRoomsQueue.Front.Weight, terrain.Weighted) {
 		current := usableRoomsQueue.Dequeue()
 		currentRoom := current.Room
-		for next, value := range currentRoom.Paths {
-			if value == BLOCKED || (!current.Mark && value == STABLE) {
-				continue
+		visit := func(next *room, value int) {
+			if value == BLOCKED || value == CLOSED || (!current.Mark && value == STABLE) {
+				return
+			}
+			addNext(currentRoom, next, current.Weight, value, value*currentRoom.length(next), usableRoomsQueue)
+		}
+		// order of next rooms is shuffled by seed
+		if order, ok := o.order[currentRoom]; ok {
+			for _, next := range order {
+				visit(next, currentRoom.Paths[next])
 			}
-			addNext(currentRoom, next, current.Weight, value, usableRoomsQueue)
+			continue
+		}
+		for next, value := range currentRoom.Paths {
+			visit(next, value)
 		}
 	}
 	isFind := endRoom.VisitIn || endRoom.VisitOut
@@ -36,15 +46,24 @@ func searchShortPath(terrain *anthive) bool {
 	return isFind
 }
 
-// addNext - add into usableRoomsQueue next room with following rules
-func addNext(cur, next *room, weight, state int, usableRoomsQueue *sortedQueue) {
+// isReached - checks end of search. If paths have lengths then
+// end room must be reached by weight less than weights of rooms in queue
+func isReached(endRoom *room, frontWeight int, weighted bool) bool {
+	if !(endRoom.VisitIn || endRoom.VisitOut) {
+		return false
+	}
+	return !weighted || frontWeight >= endRoom.Weight[0]
+}
+
+// addNext - add into usableRoomsQueue next room with following rules. cost is weight of path from cur to next
+func addNext(cur, next *room, weight, state, cost int, usableRoomsQueue *sortedQueue) {
 	// if next room isn't visited then add without checking weights
 	if !(next.VisitIn || next.VisitOut) {
 		// we'll check next room for using on previous paths (separated flag)
 		if next.Separated {
 			next.VisitIn = true
 			next.ParentIn = cur
-			next.Weight[1] = weight + state
+			next.Weight[1] = weight + cost
 			// if it's usually path between nodes then add only in_node (check Surrballe's algo)
 			if state == STABLE {
 				usableRoomsQueue.Enqueue(next, next.Weight[1], false)
@@ -53,39 +72,39 @@ func addNext(cur, next *room, weight, state int, usableRoomsQueue *sortedQueue)
 		}
 		next.VisitOut = true
 		next.ParentOut = cur
-		next.Weight[0] = weight + state
+		next.Weight[0] = weight + cost
 		usableRoomsQueue.Enqueue(next, next.Weight[0], true)
 		return
 	}
 	if !next.Separated {
-		if weight+state >= next.Weight[0] {
+		if weight+cost >= next.Weight[0] {
 			return
 		}
 		next.ParentOut = cur
-		next.Weight[0] = weight + state
+		next.Weight[0] = weight + cost
 		usableRoomsQueue.Enqueue(next, next.Weight[0], true)
 		return
 	}
 	if state == STABLE {
-		if next.VisitIn && weight+state >= next.Weight[1] {
+		if next.VisitIn && weight+cost >= next.Weight[1] {
 			return
 		}
 		next.VisitIn = true
 		next.ParentIn = cur
-		next.Weight[1] = weight + state
+		next.Weight[1] = weight + cost
 		usableRoomsQueue.Enqueue(next, next.Weight[1], false)
 		return
 	}
-	if (next.VisitIn && weight+state < next.Weight[1]) || !next.VisitIn {
+	if (next.VisitIn && weight+cost < next.Weight[1]) || !next.VisitIn {
 		next.VisitIn = true
 		next.ParentIn = cur
-		next.Weight[1] = weight + state
+		next.Weight[1] = weight + cost
 		usableRoomsQueue.Enqueue(next, next.Weight[1], false)
 	}
-	if (next.VisitOut && weight+state < next.Weight[0]) || !next.VisitOut {
+	if (next.VisitOut && weight+cost < next.Weight[0]) || !next.VisitOut {
 		next.VisitOut = true
 		next.ParentOut = cur
-		next.Weight[0] = weight + state
+		next.Weight[0] = weight + cost
 		usableRoomsQueue.Enqueue(next, next.Weight[0], true)
 	}
 }
@@ -109,7 +128,7 @@ func replaceEdges(startRoom, endRoom *room) {
 					parent = r.ParentIn
 				}
 			} else {
-				if r.Paths[r.ParentIn] == STABLE {
+				if isFree(r, r.ParentIn) {
 					parent = r.ParentIn
 				} else {
 					parent = r.ParentOut
@@ -121,25 +140,51 @@ func replaceEdges(startRoom, endRoom *room) {
 			parent = r.ParentIn
 		}
 		// reversing
-		if r.Paths[parent] == STABLE {
+		if isFree(r, parent) {
 			parent.Separated = true
 			r.Separated = true
 			r.Paths[parent] = REVERSED
 			parent.Paths[r] = BLOCKED
 		} else {
 			parent.Separated = false
-			r.Paths[parent] = STABLE
-			parent.Paths[r] = STABLE
+			r.Paths[parent] = freeState(r, parent)
+			parent.Paths[r] = freeState(parent, r)
 		}
 		r = parent
 	}
 }
 
+// isFree - returns true if path between rooms isn't used by found paths
+func isFree(r, next *room) bool {
+	return r.Paths[next] == STABLE || r.Paths[next] == CLOSED
+}
+
+// freeState - returns state of path from room to next room which isn't used
+func freeState(r, next *room) int {
+	if r.Closed[next] {
+		return CLOSED
+	}
+	return STABLE
+}
+
 // checking effective of new short path
 // current steps count < previous steps count
 // if effective then replace result to new (returns true)
 // if not then return previous result (returns false)
-func checkEffective(terrain *anthive) bool {
+func checkEffective(terrain *anthive, o *Options) bool {
+	newPaths := blockedPaths(terrain, o)
+	curStepsCount, used := fastCalcSteps(terrain.AntsCount, newPaths)
+	if terrain.StepsCount == 0 || (terrain.StepsCount >= curStepsCount && used) {
+		terrain.StepsCount = curStepsCount
+		terrain.Result.Paths = newPaths
+		return curStepsCount != 1 && !hasDirectPath(newPaths)
+	}
+	return false
+}
+
+// blockedPaths - returns the found disjoint paths (by blocked edges from start room to end room).
+// Paths go in order of next rooms of start room, so the same seed gives the same order of paths
+func blockedPaths(terrain *anthive, o *Options) []*list {
 	startRoom, endRoom := terrain.Rooms[terrain.Start], terrain.Rooms[terrain.End]
 	i, lenNewPaths := 0, 0
 	for _, value := range startRoom.Paths {
@@ -148,57 +193,56 @@ func checkEffective(terrain *anthive) bool {
 		}
 	}
 	newPaths := make([]*list, lenNewPaths)
-	for key, value := range startRoom.Paths {
-		if value == BLOCKED {
+	for _, key := range o.next(startRoom) {
+		if startRoom.Paths[key] == BLOCKED {
 			newPaths[i] = &list{}
-			cur := key
+			cur, dist := key, startRoom.length(key)
 			for cur != endRoom {
 				newPaths[i].PushBack(cur)
+				newPaths[i].Back.Dist = dist
 				for next, vNext := range cur.Paths {
 					if vNext == BLOCKED {
+						dist += cur.length(next)
 						cur = next
 						break
 					}
 				}
 			}
 			newPaths[i].PushBack(endRoom)
+			newPaths[i].Back.Dist = dist
+			newPaths[i].Dist = dist
+			newPaths[i].Direct = newPaths[i].Len == 1
 			i++
 		}
 	}
-	curStepsCount, used := fastCalcSteps(terrain.AntsCount, newPaths)
-	// For debug
-	// fmt.Printf("Steps: %d\n", curStepsCount)
-	// for i := range newPaths {
-	// 	start := newPaths[i].Front
-	// 	fmt.Printf("Len: %d | %s", newPaths[i].Len, start.Room.Name)
-	// 	for start.Room != endRoom {
-	// 		start = start.Next
-	// 		fmt.Printf(" --> %s", start.Room.Name)
-	// 	}
-	// 	fmt.Println()
-	// }
-	// fmt.Println()
-	if terrain.StepsCount == 0 || (terrain.StepsCount >= curStepsCount && used) {
-		terrain.StepsCount = curStepsCount
-		terrain.Result.Paths = newPaths
-		return curStepsCount != 1
+	return newPaths
+}
+
+// hasDirectPath - returns true if some path goes from start to end without rooms between them
+func hasDirectPath(paths []*list) bool {
+	for _, path := range paths {
+		if path.Direct {
+			return true
+		}
 	}
 	return false
 }
 
-// fastCalcSteps - calculate steps for paths and ants count
+// fastCalcSteps - calculate steps for paths and ants count.
+// Length of path is count of turns from start to end (Dist)
 func fastCalcSteps(ants int, paths []*list) (int, bool) {
 	steps, lossPerStep := 0, 0
 	max, maxUsed := 0, false
 	comingAnts := make(map[int]int)
 	for _, value := range paths {
-		comingAnts[value.Len]++
-		if max < value.Len {
-			max = value.Len
+		// all ants go together by path without rooms
+		if value.Direct {
+			return value.Dist, true
+		}
+		comingAnts[value.Dist]++
+		if max < value.Dist {
+			max = value.Dist
 		}
-	}
-	if comingAnts[1] > 0 {
-		return 1, true
 	}
 	for ants > 0 {
 		steps++
@@ -212,22 +256,25 @@ func fastCalcSteps(ants int, paths []*list) (int, bool) {
 	return steps, maxUsed
 }
 
-
 // Inputs Sorted Paths, and AntsCount should be > 0
 // Function designed for the optimal number of paths for ants count
 func calcSteps(antsCount int, sortedPaths []*list) (int, []int) {
 	if len(sortedPaths) < 1 {
 		return 0, []int{}
 	}
-	if sortedPaths[0].Len == 1 {
-		return 1, []int{antsCount}
-	}
 	// Create Result
 	lenPaths := len(sortedPaths)
 	result := make([]int, lenPaths)
-	steps, lastElem := sortedPaths[lenPaths-1].Len, sortedPaths[lenPaths-1].Len+1
+	for i := range sortedPaths {
+		// all ants go together by path without rooms
+		if sortedPaths[i].Direct {
+			result[i] = antsCount
+			return sortedPaths[i].Dist, result
+		}
+	}
+	steps, lastElem := sortedPaths[lenPaths-1].Dist, sortedPaths[lenPaths-1].Dist+1
 	for i := 0; i < lenPaths; i++ {
-		result[i] = lastElem - sortedPaths[i].Len
+		result[i] = lastElem - sortedPaths[i].Dist
 		antsCount -= result[i]
 	}
 	if antsCount > 0 {
@@ -251,46 +298,6 @@ func calcSteps(antsCount int, sortedPaths []*list) (int, []int) {
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 ////////////////////////////////////////////QUEUE MANAGEMENT///////////////////////////////////////////////////////
-func (q *antQueue) Enqueue(num, path, pos int) {
-	ant := &antStruct{
-		Num:  num,
-		Path: path,
-		Pos:  pos,
-	}
-	if q.Back == nil {
-		q.Front = ant
-		q.Back = ant
-		return
-	}
-	q.Back.Next = ant
-	q.Back = ant
-}
-
-func (q *antQueue) Dequeue() *antStruct {
-	if q.Front == nil {
-		return nil
-	}
-	res := q.Front
-	if q.Front == q.Back {
-		q.Front = nil
-		q.Back = nil
-	} else {
-		q.Front = q.Front.Next
-	}
-	return res
-}
-
-func (q *antQueue) EnqueueAnt(ant *antStruct) {
-	ant.Next = nil
-	if q.Back == nil {
-		q.Front = ant
-		q.Back = ant
-		return
-	}
-	q.Back.Next = ant
-	q.Back = ant
-}
-
 func (q *sortedQueue) Enqueue(r *room, weight int, mark bool) {
 	node := &weightNode{
 		Room:   r,
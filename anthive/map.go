@@ -0,0 +1,484 @@
+package anthive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Map - snapshot of the anthive. Using for exporters and visualizers
+type Map struct {
+	AntsCount  int
+	Start, End string         // the first start and end rooms
+	Starts     []string       // all start rooms
+	Ends       []string       // all end rooms
+	StartAnts  map[string]int // count of ants of start rooms which have it
+	Colonies   []MapColony    // in order of declaration, ants of colonies are numbered one colony after another
+	Arrivals   []Arrival      // turns when ants come into start room, nil if all ants are there from the first turn
+	Foraging   bool           // ants go to end room and back to start room
+	Avoid      []string       // rooms which ants can't visit in this run
+	Waypoints  []string       // rooms which every ant passes in this order in this run
+	Objective  string         // what the solver minimized in this run, empty is OBJECTIVE_TURNS
+	Rules      Rules          // rules of the lem-in variant of reading and moves
+	Rooms      []MapRoom      // in reading order
+	Links      []MapLink      // in reading order
+}
+
+// MapColony - colony of the Map, its ants go from Start to End room
+type MapColony struct {
+	Name       string
+	Ants       int
+	Start, End string
+}
+
+// MapRoom - room of the Map with coordinates
+type MapRoom struct {
+	Name     string
+	X, Y     int
+	Capacity int     // count of ants which room can have at the same time, 1 for classic room
+	Closed   []Turns // turns when room is closed
+}
+
+// MapLink - relation between two rooms of the Map
+type MapLink struct {
+	From, To string
+	OneWay   bool    // ants can go only from From to To
+	Length   int     // turns to pass the tunnel, 1 for classic tunnel
+	Capacity int     // ants which can go into the tunnel each turn, 0 is default (1, without limit between start and end)
+	Closed   []Turns // turns when tunnel is closed
+}
+
+// Turns - turns from From to To (both are included), turns start from 1
+type Turns struct {
+	From, To int
+}
+
+// String - returns turns as 5-9, or 5 for one turn
+func (t Turns) String() string {
+	if t.From == t.To {
+		return strconv.Itoa(t.From)
+	}
+	return strconv.Itoa(t.From) + "-" + strconv.Itoa(t.To)
+}
+
+// JoinTurns - returns turns separated by spaces: 5-9 12
+func JoinTurns(turns []Turns) string {
+	result := make([]string, len(turns))
+	for i, t := range turns {
+		result[i] = t.String()
+	}
+	return strings.Join(result, " ")
+}
+
+// Arrival - count of ants which come into start room on the turn
+type Arrival struct {
+	Turn, Ants int
+}
+
+// JoinArrivals - returns arrivals as 1:3 4:2, or as rate=N/K if N ants come every K turns from the first turn
+func JoinArrivals(arrivals []Arrival) string {
+	if len(arrivals) > 2 {
+		rate, every := arrivals[0].Ants, arrivals[1].Turn-arrivals[0].Turn
+		regular := true
+		for i, arrival := range arrivals {
+			if arrival.Turn != 1+i*every || arrival.Ants != rate && (i+1 < len(arrivals) || arrival.Ants > rate) {
+				regular = false
+			}
+		}
+		if regular && every == 1 {
+			return fmt.Sprintf("rate=%d", rate)
+		} else if regular {
+			return fmt.Sprintf("rate=%d/%d", rate, every)
+		}
+	}
+	result := make([]string, len(arrivals))
+	for i, arrival := range arrivals {
+		result[i] = fmt.Sprintf("%d:%d", arrival.Turn, arrival.Ants)
+	}
+	return strings.Join(result, " ")
+}
+
+// arrivalTurn - returns turn when the ant (numbers start from 1) comes into start room, 1 if there are no arrivals
+func arrivalTurn(arrivals []Arrival, ant int) int {
+	for _, arrival := range arrivals {
+		if ant <= arrival.Ants {
+			return arrival.Turn
+		}
+		ant -= arrival.Ants
+	}
+	return 1
+}
+
+// closedOn - returns the first turn from..to which is one of turns, 0 if there is no such turn
+func closedOn(turns []Turns, from, to int) int {
+	result := 0
+	for _, t := range turns {
+		first := t.From
+		if first < from {
+			first = from
+		}
+		if first <= t.To && first <= to && (result == 0 || first < result) {
+			result = first
+		}
+	}
+	return result
+}
+
+// Map - returns snapshot of the anthive
+func (a *anthive) Map() *Map {
+	m := &Map{
+		AntsCount: a.AntsCount,
+		Start:     a.Start,
+		End:       a.End,
+		Starts:    a.Starts,
+		Ends:      a.Ends,
+		StartAnts: a.StartAnts,
+		Arrivals:  a.Arrivals,
+		Foraging:  a.Foraging,
+		Rules:     a.Rules,
+		Rooms:     make([]MapRoom, len(a.RoomsOrder)),
+		Links:     make([]MapLink, len(a.Links)),
+	}
+	for _, c := range a.Colonies {
+		m.Colonies = append(m.Colonies, MapColony{Name: c.Name, Ants: c.Ants, Start: c.Start, End: c.End})
+	}
+	for i, r := range a.RoomsOrder {
+		m.Rooms[i] = MapRoom{Name: r.Name, X: r.X, Y: r.Y, Capacity: r.Capacity, Closed: r.Closures}
+	}
+	for i, l := range a.Links {
+		m.Links[i] = MapLink{From: l[0].Name, To: l[1].Name, Length: l[0].length(l[1]), Capacity: l[0].Capacities[l[1]], OneWay: l[1].Closed[l[0]],
+			Closed: l[0].TunnelClosures[l[1]]}
+	}
+	return m
+}
+
+// SetOptions - writes options of the run which are shown with the map
+func (m *Map) SetOptions(o Options) {
+	m.Avoid, m.Waypoints, m.Objective = o.Avoid, o.Waypoints, o.Objective
+}
+
+// tunnelClosures - returns turns when tunnel between rooms is closed
+func (m *Map) tunnelClosures(name1, name2 string) []Turns {
+	for _, l := range m.Links {
+		if l.From == name1 && l.To == name2 || l.From == name2 && l.To == name1 {
+			return l.Closed
+		}
+	}
+	return nil
+}
+
+// Length - returns length of tunnel between rooms, 0 if rooms hasn't relation
+func (m *Map) Length(name1, name2 string) int {
+	for _, l := range m.Links {
+		if l.From == name1 && l.To == name2 || l.From == name2 && l.To == name1 {
+			if l.Length < 1 {
+				return 1
+			}
+			return l.Length
+		}
+	}
+	return 0
+}
+
+// IsStart - returns true if room is one of start rooms
+func (m *Map) IsStart(name string) bool {
+	if len(m.Starts) == 0 {
+		return name == m.Start
+	}
+	for _, start := range m.Starts {
+		if start == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnd - returns true if room is one of end rooms
+func (m *Map) IsEnd(name string) bool {
+	if len(m.Ends) == 0 {
+		return name == m.End
+	}
+	for _, end := range m.Ends {
+		if end == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AntStarts - returns start room of every ant (index is number of ant) by the first move of ant.
+// If several start rooms are linked with the first room, then start rooms are assigned to ants by max flow:
+// ant -> tunnel from start room on the step (by free and open place in the tunnel after arrival of ant) -> start room -> sink,
+// start room with count of ants sends exactly this count, other start rooms send the rest of ants.
+// Ants are assigned greedily at first (start room with count of ants is preferred while it has ants), then greedy choice is fixed by augmenting paths.
+// Ant which can't be assigned takes the first linked start room, so checking of moves finds the error. Ant of colony goes from start room of its colony
+func (m *Map) AntStarts(moves [][]Move) []string {
+	result := make([]string, m.AntsCount+1)
+	for i := range result {
+		result[i] = m.Start
+		if c := m.antColony(i); c != nil {
+			result[i] = c.Start
+		}
+	}
+	if len(m.Starts) < 2 || len(m.Colonies) > 0 {
+		return result
+	}
+	// count of ants which went into the tunnel from start room on the step
+	type entry struct {
+		From, To string
+		Step     int
+	}
+	entered := make(map[entry]int)
+	// ants of foraging which come back take places in tunnels of start rooms
+	prev := make([]string, m.AntsCount+1)
+	for i, step := range moves {
+		for _, move := range step {
+			if move.Ant < 1 || move.Ant > m.AntsCount {
+				continue
+			} else if move.Back && m.IsStart(move.Room) && prev[move.Ant] != "" {
+				entered[entry{From: move.Room, To: prev[move.Ant], Step: i + 2 - m.Length(move.Room, prev[move.Ant])}]++
+			}
+			prev[move.Ant] = move.Room
+		}
+	}
+	// nodes: source, sink, start rooms (start rooms without count of ants share one node), ants, tunnels on steps
+	n := &network{Adj: make([][]int, 3+len(m.Starts))}
+	source, sink, free := 0, 1, 2
+	startNode := make(map[string]int, len(m.Starts))
+	left := make(map[int]int) // places of start and tunnel nodes for greedy choice
+	left[free] = m.AntsCount
+	for i, start := range m.Starts {
+		if ants, ok := m.StartAnts[start]; ok {
+			startNode[start] = 3 + i
+			left[3+i] = ants
+			left[free] -= ants
+		} else {
+			startNode[start] = free
+		}
+	}
+	sinkArc := make(map[int]int) // arc from start node to sink by start node
+	for node := free; node < len(n.Adj); node++ {
+		sinkArc[node] = len(n.Arcs)
+		n.addArc(node, sink, left[node], 0, false)
+	}
+	tunnelNode := make(map[entry]int)
+	tunnelArc := make(map[entry]int) // arc from tunnel node to start node
+	antNode := make(map[int]int)
+	antArc := make(map[int][]int)   // arcs from ant to tunnel nodes
+	tunnelOf := make(map[int]entry) // tunnel by arc from ant
+	found := make([]bool, m.AntsCount+1)
+	var ants []int // ants in order of their first moves
+	for i, step := range moves {
+		for _, move := range step {
+			if move.Ant < 1 || move.Ant > m.AntsCount || found[move.Ant] {
+				continue
+			}
+			found[move.Ant] = true
+			first := ""
+			for _, start := range m.Starts {
+				if !m.canGo(start, move.Room) {
+					continue
+				} else if first == "" {
+					first = start
+				}
+				// ant can't go into the closed tunnel, and it can't leave start room before it comes there
+				tunnel := entry{From: start, To: move.Room, Step: i + 2 - m.Length(start, move.Room)}
+				if closedOn(m.tunnelClosures(start, move.Room), tunnel.Step, i+1) != 0 || tunnel.Step < arrivalTurn(m.Arrivals, move.Ant) {
+					continue
+				}
+				if _, ok := tunnelNode[tunnel]; !ok {
+					tunnelNode[tunnel] = len(n.Adj)
+					n.Adj = append(n.Adj, nil)
+					left[tunnelNode[tunnel]] = m.tunnelCapacity(start, move.Room) - entered[tunnel]
+					tunnelArc[tunnel] = len(n.Arcs)
+					n.addArc(tunnelNode[tunnel], startNode[start], left[tunnelNode[tunnel]], 0, false)
+				}
+				if len(antArc[move.Ant]) == 0 {
+					ants = append(ants, move.Ant)
+					antNode[move.Ant] = len(n.Adj)
+					n.Adj = append(n.Adj, nil)
+				}
+				antArc[move.Ant] = append(antArc[move.Ant], len(n.Arcs))
+				tunnelOf[len(n.Arcs)] = tunnel
+				n.addArc(antNode[move.Ant], tunnelNode[tunnel], 1, 0, false)
+			}
+			if first != "" {
+				result[move.Ant] = first
+			}
+		}
+	}
+	// start room with count of ants is better than start room without count, and start room without places is the worst
+	rank := func(tunnel entry) int {
+		node := startNode[tunnel.From]
+		if left[tunnelNode[tunnel]] <= 0 || left[node] <= 0 {
+			return 0
+		} else if node == free {
+			return 1
+		}
+		return 2
+	}
+	for _, ant := range ants {
+		sourceArc := len(n.Arcs)
+		n.addArc(source, antNode[ant], 1, 0, false)
+		best := -1
+		for _, i := range antArc[ant] {
+			if best == -1 || rank(tunnelOf[i]) > rank(tunnelOf[best]) {
+				best = i
+			}
+		}
+		tunnel := tunnelOf[best]
+		if rank(tunnel) == 0 {
+			continue
+		}
+		// greedy choice is the flow source -> ant -> tunnel -> start node -> sink
+		node := startNode[tunnel.From]
+		for _, i := range []int{sourceArc, best, tunnelArc[tunnel], sinkArc[node]} {
+			n.Arcs[i].Flow++
+			n.Arcs[i^1].Flow--
+		}
+		left[tunnelNode[tunnel]]--
+		left[node]--
+	}
+	for n.augment(source, sink) {
+	}
+	for _, ant := range ants {
+		for _, i := range antArc[ant] {
+			if n.Arcs[i].Flow > 0 {
+				result[ant] = tunnelOf[i].From
+			}
+		}
+	}
+	return result
+}
+
+// tunnelCapacity - returns count of ants which can go into tunnel each step.
+// Tunnel between start and end room hasn't limit by default
+func (m *Map) tunnelCapacity(name1, name2 string) int {
+	for _, l := range m.Links {
+		if l.From == name1 && l.To == name2 || l.From == name2 && l.To == name1 {
+			if l.Capacity > 0 {
+				return l.Capacity
+			} else if m.IsStart(name1) && m.IsEnd(name2) || m.IsEnd(name1) && m.IsStart(name2) {
+				return m.AntsCount
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// antColony - returns colony of ant (ants of colonies are numbered one colony after another), nil if map hasn't colonies
+func (m *Map) antColony(ant int) *MapColony {
+	for i := range m.Colonies {
+		if ant <= m.Colonies[i].Ants {
+			return &m.Colonies[i]
+		}
+		ant -= m.Colonies[i].Ants
+	}
+	return nil
+}
+
+// RoomColony - returns colony of start or end room, nil if room doesn't belong to colony
+func (m *Map) RoomColony(name string) *MapColony {
+	for i := range m.Colonies {
+		if m.Colonies[i].Start == name || m.Colonies[i].End == name {
+			return &m.Colonies[i]
+		}
+	}
+	return nil
+}
+
+// AntName - returns name of ant in result line: L<ant>, or L<colony>.<n> where n is number of ant in its colony
+func (m *Map) AntName(ant int) string {
+	for _, c := range m.Colonies {
+		if ant <= c.Ants {
+			return fmt.Sprintf("L%s.%d", c.Name, ant)
+		}
+		ant -= c.Ants
+	}
+	return fmt.Sprintf("L%d", ant)
+}
+
+// canGo - returns true if ants can go from room to next room
+func (m *Map) canGo(name, next string) bool {
+	for _, l := range m.Links {
+		if l.From == name && l.To == next || !l.OneWay && l.From == next && l.To == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Room - returns room of the Map by name, nil if not found
+func (m *Map) Room(name string) *MapRoom {
+	for i := range m.Rooms {
+		if m.Rooms[i].Name == name {
+			return &m.Rooms[i]
+		}
+	}
+	return nil
+}
+
+// Linked - returns true if rooms has relation
+func (m *Map) Linked(name1, name2 string) bool {
+	for _, l := range m.Links {
+		if l.From == name1 && l.To == name2 || l.From == name2 && l.To == name1 {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteMap - writes the Map in lem-in format
+func (m *Map) WriteMap(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, m.AntsCount)
+	for _, c := range m.Colonies {
+		fmt.Fprintf(out, "##colony %s %d\n", c.Name, c.Ants)
+	}
+	if m.Arrivals != nil {
+		fmt.Fprintf(out, "##arrivals %s\n", JoinArrivals(m.Arrivals))
+	}
+	if m.Foraging {
+		fmt.Fprintln(out, "##foraging")
+	}
+	for _, r := range m.Rooms {
+		if c := m.RoomColony(r.Name); c != nil && c.Start == r.Name {
+			fmt.Fprintf(out, "##start %s\n", c.Name)
+		} else if c != nil {
+			fmt.Fprintf(out, "##end %s\n", c.Name)
+		} else if ants, ok := m.StartAnts[r.Name]; ok {
+			fmt.Fprintf(out, "##start %d\n", ants)
+		} else if m.IsStart(r.Name) {
+			fmt.Fprintln(out, "##start")
+		} else if m.IsEnd(r.Name) {
+			fmt.Fprintln(out, "##end")
+		} else if r.Capacity > 1 {
+			fmt.Fprintf(out, "##capacity %d\n", r.Capacity)
+		}
+		if r.Closed != nil {
+			fmt.Fprintf(out, "##closed %s\n", JoinTurns(r.Closed))
+		}
+		fmt.Fprintf(out, "%s %d %d\n", r.Name, r.X, r.Y)
+	}
+	for _, l := range m.Links {
+		if l.Closed != nil {
+			fmt.Fprintf(out, "##closed %s\n", JoinTurns(l.Closed))
+		}
+		if l.OneWay {
+			fmt.Fprintf(out, "%s>%s", l.From, l.To)
+		} else {
+			fmt.Fprintf(out, "%s-%s", l.From, l.To)
+		}
+		if l.Length > 1 {
+			fmt.Fprintf(out, " %d", l.Length)
+		}
+		if l.Capacity > 0 {
+			fmt.Fprintf(out, " cap=%d", l.Capacity)
+		}
+		fmt.Fprintln(out)
+	}
+	return out.Flush()
+}
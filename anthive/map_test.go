@@ -0,0 +1,60 @@
+package anthive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAntStarts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		moves   string
+		starts  []string // start room of every ant from 1
+	}{
+		{
+			name: "ant of start room without count goes first",
+			// r0 sends exactly one ant and only r0 is linked with r5, so L1 must go from r1
+			content: "3\n##start 1\nr0 0 0\n##start\nr1 1 1\n##end\nr5 2 2\n##end\nr6 3 3\nr6-r1\nr0-r5\nr0-r6\n",
+			moves:   "L1-r6 L2-r6 L3-r5",
+			starts:  []string{"r1", "r1", "r0"},
+		},
+		{
+			name:    "start room with count is preferred",
+			content: "2\n##start 1\ns1 0 0\n##start\ns2 1 1\na 2 2\n##end\ne 3 3\ns1-a\ns2-a\na-e\n",
+			moves:   "L1-a\nL1-e L2-a\nL2-e",
+			starts:  []string{"s1", "s2"},
+		},
+		{
+			name: "full tunnel",
+			// both ants enter a on the first turn, tunnels from start rooms take one ant
+			content: "2\n##start\ns1 0 0\n##start\ns2 1 1\n##capacity 2\na 2 2\n##end\ne 3 3\ns1-a\ns2-a\na-e cap=2\n",
+			moves:   "L1-a L2-a\nL1-e L2-e",
+			starts:  []string{"s1", "s2"},
+		},
+	}
+	for _, test := range tests {
+		m := readTest(t, test.name, test.content).Map()
+		moves := parseTestMoves(t, test.moves)
+		starts := m.AntStarts(moves)[1:]
+		if strings.Join(starts, " ") != strings.Join(test.starts, " ") {
+			t.Errorf("%s: starts %v, want %v", test.name, starts, test.starts)
+		}
+		if err := m.Verify(moves); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+	}
+}
+
+// parseTestMoves - returns moves of lines
+func parseTestMoves(t *testing.T, content string) [][]Move {
+	var moves [][]Move
+	for _, line := range strings.Split(content, "\n") {
+		step, err := ParseMoves(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves = append(moves, step)
+	}
+	return moves
+}
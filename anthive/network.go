@@ -0,0 +1,298 @@
+package anthive
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// General solver for the maps which are out of classic rules (rooms and tunnels with capacity).
+// Every room is split into in-node and out-node, arc between them has capacity of the room.
+// Tunnel is a pair of arcs out-node -> in-node (one arc for one-way tunnel) with capacity of tunnel and cost = length of tunnel.
+// Paths are added one by one (min cost flow), the best count of paths is saved
+
+// flowArc - arc of the network. Arcs i and i^1 are pair: direct and residual
+type flowArc struct {
+	From, To  int
+	Cap, Cost int
+	Flow      int
+	Tunnel    bool // arc of tunnel (not of room)
+}
+
+// network - graph of the anthive for min cost flow
+type network struct {
+	Arcs []flowArc
+	Adj  [][]int // indexes of arcs by node
+}
+
+// nodeIn, nodeOut - nodes of the room in the network
+func nodeIn(i int) int  { return 2 * i }
+func nodeOut(i int) int { return 2*i + 1 }
+
+// roomOfNode - index of room in RoomsOrder
+func roomOfNode(node int) int { return node / 2 }
+
+func (n *network) addArc(from, to, cap, cost int, tunnel bool) {
+	n.Adj[from] = append(n.Adj[from], len(n.Arcs))
+	n.Arcs = append(n.Arcs, flowArc{From: from, To: to, Cap: cap, Cost: cost, Tunnel: tunnel})
+	n.Adj[to] = append(n.Adj[to], len(n.Arcs))
+	n.Arcs = append(n.Arcs, flowArc{From: to, To: from, Cap: 0, Cost: -cost, Tunnel: tunnel})
+}
+
+// buildNetwork - returns network of the anthive, its source and sink.
+// Source is linked with start rooms (by count of their ants), end rooms are linked with sink.
+// If colony isn't nil, then only its start and end rooms are linked, capacities are reduced by used places
+// and rooms have extra cost by penalty of used (used can be nil).
+// Ants can't go through start and end rooms. Tunnel between start and end without capacity isn't added, it's checked separately
+func (a *anthive) buildNetwork(o *Options, c *colony, used *usage) (n *network, source, sink int) {
+	index := make(map[*room]int, len(a.RoomsOrder))
+	for i, r := range a.RoomsOrder {
+		index[r] = i
+	}
+	n = &network{Adj: make([][]int, 2*len(a.RoomsOrder)+2)}
+	source, sink = 2*len(a.RoomsOrder), 2*len(a.RoomsOrder)+1
+	for i, r := range a.RoomsOrder {
+		if a.isStart(r.Name) && (c == nil || c.Start == r.Name) {
+			ants, ok := a.StartAnts[r.Name]
+			if !ok {
+				ants = a.freeAnts()
+			}
+			n.addArc(source, nodeOut(i), ants, 0, false)
+		} else if a.isEnd(r.Name) && (c == nil || c.End == r.Name) {
+			n.addArc(nodeIn(i), sink, a.AntsCount, 0, false)
+		} else if !a.isStart(r.Name) && !a.isEnd(r.Name) {
+			n.addArc(nodeIn(i), nodeOut(i), r.Capacity-used.room(r), used.penalty(r), false)
+		}
+	}
+	a.addTunnels(o, n, index, used)
+	return n, source, sink
+}
+
+// addTunnels - adds arcs of tunnels into the network, index is index of room in RoomsOrder.
+// Capacities are reduced by used places (used can be nil), tunnel between start and end without capacity isn't added
+func (a *anthive) addTunnels(o *Options, n *network, index map[*room]int, used *usage) {
+	for _, l := range o.links(a) {
+		if _, ok := l[0].Capacities[l[1]]; !ok && a.isDirect(l[0], l[1]) {
+			continue
+		}
+		i, j := index[l[0]], index[l[1]]
+		length, capacity := l[0].length(l[1]), a.linkCapacity(l[0], l[1])-used.tunnel(tunnelKey(l[0], l[1]))
+		if l[0].canGo(l[1]) {
+			n.addArc(nodeOut(i), nodeIn(j), capacity, length, true)
+		}
+		if l[1].canGo(l[0]) {
+			n.addArc(nodeOut(j), nodeIn(i), capacity, length, true)
+		}
+	}
+}
+
+// augment - sends one more ant by the cheapest path of residual network (label-correcting search),
+// returns false if sink isn't reachable
+func (n *network) augment(source, sink int) bool {
+	dist := make([]int, len(n.Adj))
+	parent := make([]int, len(n.Adj))
+	inQueue := make([]bool, len(n.Adj))
+	for i := range dist {
+		dist[i] = math.MaxInt32
+		parent[i] = -1
+	}
+	dist[source] = 0
+	queue := []int{source}
+	inQueue[source] = true
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		inQueue[cur] = false
+		for _, i := range n.Adj[cur] {
+			arc := &n.Arcs[i]
+			if arc.Cap-arc.Flow > 0 && dist[cur]+arc.Cost < dist[arc.To] {
+				dist[arc.To] = dist[cur] + arc.Cost
+				parent[arc.To] = i
+				if !inQueue[arc.To] {
+					inQueue[arc.To] = true
+					queue = append(queue, arc.To)
+				}
+			}
+		}
+	}
+	if parent[sink] == -1 {
+		return false
+	}
+	for cur := sink; cur != source; cur = n.Arcs[parent[cur]].From {
+		n.Arcs[parent[cur]].Flow++
+		n.Arcs[parent[cur]^1].Flow--
+	}
+	return true
+}
+
+// paths - splits flow of the network into paths of rooms (without start room)
+func (n *network) paths(a *anthive) []*list {
+	return n.pathsBetween(a, a.isStart, a.isEnd)
+}
+
+// pathsBetween - splits flow of the network into paths of rooms from first rooms (they are Start of paths) to last rooms
+func (n *network) pathsBetween(a *anthive, isFirst, isLast func(name string) bool) []*list {
+	next := make(map[int][]int) // tunnel arcs with flow by node
+	for i := 0; i < len(n.Arcs); i += 2 {
+		arc := n.Arcs[i]
+		for f := 0; arc.Tunnel && f < arc.Flow; f++ {
+			next[arc.From] = append(next[arc.From], i)
+		}
+	}
+	var result []*list
+	for i, startRoom := range a.RoomsOrder {
+		if !isFirst(startRoom.Name) {
+			continue
+		}
+		for len(next[nodeOut(i)]) > 0 {
+			path := &list{Start: startRoom}
+			cur, dist := nodeOut(i), 0
+			for {
+				arcs := next[cur]
+				arc := n.Arcs[arcs[len(arcs)-1]]
+				next[cur] = arcs[:len(arcs)-1]
+				dist += arc.Cost
+				r := a.RoomsOrder[roomOfNode(arc.To)]
+				path.PushBack(r)
+				path.Back.Dist = dist
+				if isLast(r.Name) {
+					break
+				}
+				cur = nodeOut(roomOfNode(arc.To))
+			}
+			path.Dist = dist
+			result = append(result, path)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Dist < result[j].Dist })
+	return result
+}
+
+// usefulPaths - returns the best count of the shortest paths for ants and count of steps
+func usefulPaths(ants int, sortedPaths []*list) ([]*list, int) {
+	best, bestSteps := 0, 0
+	for count := 1; count <= len(sortedPaths); count++ {
+		// every path must take at least one ant
+		need, last := 0, sortedPaths[count-1].Dist
+		for _, path := range sortedPaths[:count] {
+			need += last - path.Dist + 1
+		}
+		if need > ants && count > 1 {
+			break
+		}
+		steps, _ := calcSteps(ants, sortedPaths[:count])
+		if best == 0 || steps < bestSteps {
+			best, bestSteps = count, steps
+		}
+	}
+	return sortedPaths[:best], bestSteps
+}
+
+// needsNetwork - returns true if the anthive can't be solved by classic search of disjoint paths
+func (a *anthive) needsNetwork() bool {
+	return a.Wide || a.Galleries || len(a.Starts) > 1 || len(a.Ends) > 1
+}
+
+// matchNetwork - finds paths by min cost flow. Paths can have common rooms and tunnels if their capacity allows it
+func (a *anthive) matchNetwork(o *Options) error {
+	n, source, sink := a.buildNetwork(o, nil, nil)
+	paths, steps := a.choosePaths(nil)
+	for flow := 0; flow < a.AntsCount && n.augment(source, sink); flow++ {
+		if curPaths, curSteps := a.choosePaths(n.paths(a)); curSteps > 0 && (steps == 0 || curSteps < steps) {
+			paths, steps = curPaths, curSteps
+		}
+	}
+	if steps == 0 {
+		return errors.New("path not found")
+	}
+	a.StepsCount = steps
+	a.Result.Paths = paths
+	a.Result.Map = a.Map()
+	return nil
+}
+
+// choosePaths - chooses the best paths for ants of every start room with count of ants
+// and for other ants (they go from start rooms without count). Returns 0 steps if some ants can't reach end
+func (a *anthive) choosePaths(sortedPaths []*list) ([]*list, int) {
+	groups := make(map[string][]*list)
+	for _, path := range sortedPaths {
+		groups[a.groupOf(path.Start.Name)] = append(groups[a.groupOf(path.Start.Name)], path)
+	}
+	var result []*list
+	steps := 0
+	for _, group := range append([]string{""}, a.Starts...) {
+		ants, ok := a.StartAnts[group]
+		if group == "" {
+			ants = a.freeAnts()
+		} else if !ok {
+			continue
+		}
+		if ants == 0 {
+			continue
+		}
+		paths, groupSteps := a.bestPaths(group, ants, groups[group], a.Ends)
+		if groupSteps == 0 {
+			return nil, 0
+		}
+		result = append(result, paths...)
+		if groupSteps > steps {
+			steps = groupSteps
+		}
+	}
+	return result, steps
+}
+
+// bestPaths - returns the best count of the shortest paths or path without rooms (to one of ends) for ants of the group
+func (a *anthive) bestPaths(group string, ants int, sortedPaths []*list, ends []string) ([]*list, int) {
+	paths, steps := []*list{}, 0
+	if len(sortedPaths) > 0 {
+		paths, steps = usefulPaths(ants, sortedPaths)
+	}
+	// all ants go together by path without rooms and limit
+	if direct := a.directPath(group, ends); direct != nil && (steps == 0 || direct.Dist <= steps) {
+		paths, steps = []*list{direct}, direct.Dist
+	}
+	return paths, steps
+}
+
+// groupOf - returns start room if it has count of ants, or empty string for ants of other start rooms
+func (a *anthive) groupOf(start string) string {
+	if _, ok := a.StartAnts[start]; ok {
+		return start
+	}
+	return ""
+}
+
+// directPath - returns the shortest path without rooms and limit from start rooms of the group to one of ends,
+// nil if there is no such path
+func (a *anthive) directPath(group string, ends []string) *list {
+	var result *list
+	for _, path := range a.directPaths(group, ends) {
+		if result == nil || path.Dist < result.Dist {
+			result = path
+		}
+	}
+	return result
+}
+
+// directPaths - returns all paths without rooms and limit from start rooms of the group to ends
+func (a *anthive) directPaths(group string, ends []string) []*list {
+	var result []*list
+	for _, start := range a.Starts {
+		if a.groupOf(start) != group {
+			continue
+		}
+		startRoom := a.Rooms[start]
+		for _, end := range ends {
+			endRoom := a.Rooms[end]
+			if !startRoom.canGo(endRoom) || startRoom.Capacities[endRoom] != 0 {
+				continue
+			}
+			path := &list{Start: startRoom, Dist: startRoom.length(endRoom), Direct: true}
+			path.PushBack(endRoom)
+			path.Back.Dist = path.Dist
+			result = append(result, path)
+		}
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package anthive
+
+import "testing"
+
+func TestMatchRoomCapacity(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+		room    string
+		ants    int // the most ants in the room at the same turn
+	}{
+		{"paths share wide room", "4\n##start\ns 0 0\na 1 0\nb 1 2\n##capacity 2\nw 2 1\nc 3 0\nd 3 2\n##end\ne 4 1\ns-a\ns-b\na-w\nb-w\nw-c\nw-d\nc-e\nd-e\n", 5, "w", 2},
+		{"room of one ant is one path", "4\n##start\ns 0 0\na 1 0\nb 1 2\n##capacity 1\nw 2 1\nc 3 0\nd 3 2\n##end\ne 4 1\ns-a\ns-b\na-w\nb-w\nw-c\nw-d\nc-e\nd-e\n", 7, "w", 1},
+		{"tunnel takes one ant per turn", "3\n##start\ns 0 0\n##capacity 2\nw 1 0\n##end\ne 2 0\ns-w\nw-e\n", 4, "w", 1},
+	}
+	for _, test := range tests {
+		_, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		if ants := maxAnts(antRooms(moves), test.room); ants != test.ants {
+			t.Errorf("%s: %d ants in room '%s' at once, want %d", test.name, ants, test.room, test.ants)
+		}
+	}
+}
+
+func TestMatchTunnelCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		turns    int
+		from, to string
+		ants     int // the most ants which go into the tunnel at the same turn
+	}{
+		{"wide tunnels into wide room", "4\n##start\ns 0 0\n##capacity 2\na 1 0\n##end\ne 2 0\ns-a cap=2\na-e cap=2\n", 3, "a", "e", 2},
+		{"tunnel between start and end with limit", "3\n##start\ns 0 0\n##end\ne 2 0\ns-e cap=1\n", 3, "s", "e", 1},
+		{"tunnel between start and end without limit", "3\n##start\ns 0 0\n##end\ne 2 0\ns-e\n", 1, "s", "e", 3},
+		{"long wide tunnel and short path", "4\n##start\ns 0 0\n##capacity 2\na 1 0\nb 1 2\n##end\ne 2 0\ns-a cap=2\na-e 2 cap=2\ns-b\nb-e\n", 3, "s", "a", 2},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		if ants := maxEntries(a.Start, moves, test.from, test.to); ants != test.ants {
+			t.Errorf("%s: %d ants go into tunnel '%s-%s' at once, want %d", test.name, ants, test.from, test.to, test.ants)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package anthive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Objectives of the solver. By default the least count of turns is searched (checkEffective),
+// other objectives are searched for classic maps: every set of disjoint paths (from one path to max flow) is scheduled
+// by the objective and the best schedule is chosen
+const (
+	OBJECTIVE_TURNS       = "turns"       // the least count of turns
+	OBJECTIVE_MOVES       = "moves"       // the least sum of lengths of paths of all ants, then the least count of turns
+	OBJECTIVE_WAIT        = "wait"        // the least waiting of ant in start room, then the least count of turns
+	OBJECTIVE_TURNS_MOVES = "turns-moves" // the least count of turns, then the least sum of lengths of paths of all ants
+)
+
+// Objectives - all objectives in order of report
+var Objectives = []string{OBJECTIVE_TURNS, OBJECTIVE_MOVES, OBJECTIVE_WAIT, OBJECTIVE_TURNS_MOVES}
+
+// Costs - costs of the schedule which are compared by objectives
+type Costs struct {
+	Objective string `json:"objective"` // objective of the solver
+	Turns     int    `json:"turns"`     // count of turns
+	Moves     int    `json:"moves"`     // sum of lengths of paths of all ants (count of moves if tunnels have length 1)
+	Wait      int    `json:"wait"`      // max count of turns which ant waits in start room after its arrival
+}
+
+// Rules for Objective:
+// Objective is one of Objectives, empty objective is OBJECTIVE_TURNS
+// Objectives other than OBJECTIVE_TURNS are supported only for classic maps: one start and one end room, rooms and tunnels without capacity,
+// without closures, arrivals, foraging, waypoints and given paths
+
+// validateObjective - returns error if the objective is unknown or isn't supported for the anthive
+func (a *anthive) validateObjective(o *Options) error {
+	objective := o.Objective
+	known := objective == ""
+	for _, name := range Objectives {
+		known = known || objective == name
+	}
+	if !known {
+		return fmt.Errorf("unknown objective '%v'", objective)
+	} else if objective == "" || objective == OBJECTIVE_TURNS {
+		return nil
+	} else if len(a.Colonies) > 0 || a.Foraging || len(o.Waypoints) > 0 || a.Timed || a.Arrivals != nil || o.pinned != nil || a.needsNetwork() {
+		return fmt.Errorf("objective '%v' is supported only for classic maps", objective)
+	}
+	return nil
+}
+
+// matchObjective - finds every set of disjoint paths and chooses the best schedule by the objective
+func (a *anthive) matchObjective(o *Options) error {
+	var best []*list
+	var bestCosts Costs
+	for searchShortPath(a, o) {
+		paths := blockedPaths(a, o)
+		sort.SliceStable(paths, func(i, j int) bool { return paths[i].Dist < paths[j].Dist })
+		curPaths, curCosts := planObjective(o.Objective, a.AntsCount, paths)
+		if best == nil || better(o.Objective, curCosts, bestCosts) {
+			best, bestCosts = curPaths, curCosts
+		}
+		if len(paths) >= a.AntsCount || hasDirectPath(paths) {
+			break
+		}
+	}
+	if best == nil {
+		return errors.New("path not found")
+	}
+	a.StepsCount = bestCosts.Turns
+	a.Result.Paths = best
+	a.Result.Map = a.Map()
+	return nil
+}
+
+// planObjective - sends ants by sorted disjoint paths (every path takes one ant every turn) by the objective.
+// Returns paths which take ants with steps of departures and costs of the schedule
+func planObjective(objective string, ants int, paths []*list) ([]*list, Costs) {
+	costs := Costs{Objective: objective}
+	// all ants go together by path without rooms
+	for _, path := range paths {
+		if path.Direct {
+			direct := *path
+			direct.Departures = make([]int, ants)
+			costs.Turns, costs.Moves = path.Dist, ants*path.Dist
+			return []*list{&direct}, costs
+		}
+	}
+	wait := ants // every path can take all ants
+	if objective == OBJECTIVE_MOVES {
+		// only the shortest paths
+		count := 1
+		for count < len(paths) && paths[count].Dist == paths[0].Dist {
+			count++
+		}
+		paths = paths[:count]
+	} else if objective == OBJECTIVE_WAIT {
+		wait = (ants+len(paths)-1)/len(paths) - 1
+	}
+	// the least count of turns when paths can take all ants, ant of path waits in start room no more than wait turns
+	counts := make([]int, len(paths))
+	for turns := paths[0].Dist; ; turns++ {
+		left := ants
+		for i, path := range paths {
+			counts[i] = turns - path.Dist + 1
+			if counts[i] < 0 {
+				counts[i] = 0
+			} else if counts[i] > wait+1 {
+				counts[i] = wait + 1
+			}
+			// shorter paths take ants first
+			if counts[i] > left {
+				counts[i] = left
+			}
+			left -= counts[i]
+		}
+		if left == 0 {
+			costs.Turns = turns
+			break
+		}
+	}
+	var result []*list
+	for i, path := range paths {
+		if counts[i] == 0 {
+			continue
+		}
+		planned := *path
+		planned.Departures = make([]int, counts[i])
+		for j := range planned.Departures {
+			planned.Departures[j] = j
+		}
+		costs.Moves += counts[i] * path.Dist
+		if counts[i]-1 > costs.Wait {
+			costs.Wait = counts[i] - 1
+		}
+		result = append(result, &planned)
+	}
+	return result, costs
+}
+
+// better - returns true if costs are better than other costs by the objective
+func better(objective string, costs, other Costs) bool {
+	order := []int{costs.Turns, costs.Moves, costs.Wait}
+	otherOrder := []int{other.Turns, other.Moves, other.Wait}
+	if objective == OBJECTIVE_MOVES {
+		order = []int{costs.Moves, costs.Turns, costs.Wait}
+		otherOrder = []int{other.Moves, other.Turns, other.Wait}
+	} else if objective == OBJECTIVE_WAIT {
+		order = []int{costs.Wait, costs.Turns, costs.Moves}
+		otherOrder = []int{other.Wait, other.Turns, other.Moves}
+	}
+	for i := range order {
+		if order[i] != otherOrder[i] {
+			return order[i] < otherOrder[i]
+		}
+	}
+	return false
+}
+
+// Costs - returns costs of the result: count of turns, sum of lengths of paths of all ants and max waiting in start room
+func (r *Result) Costs() Costs {
+	r.sortPaths()
+	steps, antsForEachPath := r.distribution()
+	costs := Costs{Objective: OBJECTIVE_TURNS, Turns: steps}
+	if r.Map != nil && r.Map.Objective != "" {
+		costs.Objective = r.Map.Objective
+	}
+	var departures []int
+	for i, path := range r.Paths {
+		costs.Moves += antsForEachPath[i] * path.Dist
+		if path.Departures != nil {
+			departures = append(departures, path.Departures...)
+			continue
+		}
+		for j := 0; j < antsForEachPath[i]; j++ {
+			// all ants go together by path without rooms
+			if path.Direct {
+				departures = append(departures, 0)
+			} else {
+				departures = append(departures, j)
+			}
+		}
+	}
+	// ants come into start room in order of their numbers, and they are numbered by departures
+	sort.Ints(departures)
+	for i, departure := range departures {
+		arrival := 1
+		if r.Map != nil {
+			arrival = arrivalTurn(r.Map.Arrivals, i+1)
+		}
+		if wait := departure - (arrival - 1); wait > costs.Wait {
+			costs.Wait = wait
+		}
+	}
+	return costs
+}
+
+// WriteCosts - writes costs of results side by side, one line for every objective
+func WriteCosts(w io.Writer, costs []Costs) error {
+	fmt.Fprintf(w, "%-12s %6s %6s %6s\n", "objective", "turns", "moves", "wait")
+	for _, c := range costs {
+		_, err := fmt.Fprintf(w, "%-12s %6d %6d %6d\n", c.Objective, c.Turns, c.Moves, c.Wait)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCostsJSON - writes costs of results as JSON document
+func WriteCostsJSON(w io.Writer, costs []Costs) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(costs)
+}
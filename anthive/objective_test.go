@@ -0,0 +1,49 @@
+package anthive
+
+import "testing"
+
+func TestMatchObjective(t *testing.T) {
+	twoPaths := "4\n##start\ns 0 0\na 1 0\nb 1 2\nc 2 2\nd 3 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-d\nd-e\n"
+	example := "6" + exampleMap[1:]
+	tests := []struct {
+		name      string
+		content   string
+		objective string
+		costs     Costs
+	}{
+		{"turns by two paths", twoPaths, OBJECTIVE_TURNS, Costs{Turns: 4, Moves: 10, Wait: 2}},
+		{"moves by short path", twoPaths, OBJECTIVE_MOVES, Costs{Turns: 5, Moves: 8, Wait: 3}},
+		{"wait by two paths", twoPaths, OBJECTIVE_WAIT, Costs{Turns: 5, Moves: 12, Wait: 1}},
+		{"turns then moves", twoPaths, OBJECTIVE_TURNS_MOVES, Costs{Turns: 4, Moves: 10, Wait: 2}},
+		{"moves of example", example, OBJECTIVE_MOVES, Costs{Turns: 8, Moves: 18, Wait: 5}},
+		{"wait of example", example, OBJECTIVE_WAIT, Costs{Turns: 6, Moves: 21, Wait: 2}},
+	}
+	for _, test := range tests {
+		a, _ := solveTest(t, test.name, test.content, &Options{Objective: test.objective}, test.costs.Turns)
+		costs := a.Result.Costs()
+		test.costs.Objective = test.objective
+		if costs != test.costs {
+			t.Errorf("%s: costs %+v, want %+v", test.name, costs, test.costs)
+		}
+	}
+}
+
+func TestValidateObjective(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		objective string
+		err       string
+	}{
+		{"unknown objective", exampleMap, "energy", "unknown objective 'energy'"},
+		{"objective of wide rooms", "2\n##start\ns 0 0\n##capacity 2\na 1 0\n##end\ne 2 0\ns-a\na-e\n", OBJECTIVE_MOVES, "objective 'moves' is supported only for classic maps"},
+		{"turns of wide rooms", "2\n##start\ns 0 0\n##capacity 2\na 1 0\n##end\ne 2 0\ns-a\na-e\n", OBJECTIVE_TURNS, ""},
+	}
+	for _, test := range tests {
+		a := readTest(t, test.name, test.content)
+		err := a.SetOptions(&Options{Objective: test.objective})
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: error %v, want '%v'", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package anthive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Solver for the given paths: ants are sent by exactly these paths with timetable, every ant takes the path where it arrives first.
+// If the solver fills the rest, then rooms and tunnels of the given paths are taken, the other paths are added one by one
+// from min cost flow of the free places and the timetable with the least count of turns is chosen
+
+// Rules for Paths:
+// File of paths has one path in every line: rooms from start room to end room separated by '-' (s-a-b-e).
+// Empty lines and lines started with '#' are skipped.
+// Path is simple (room is passed once), it goes through tunnels of the map from start room to end room without other start and end rooms.
+// Paths are vertex-disjoint: room (except start and end rooms) belongs to one path, the same path can't be given twice.
+// In edge-disjoint mode paths can share rooms, but tunnel belongs to one path.
+// Ants of every start room with count of ants must have a path, if the solver doesn't fill the rest.
+// Paths can't be used with colonies, foraging, waypoints and objectives other than turns
+
+// ParsePaths - returns rooms of paths from content of the file of paths
+func ParsePaths(content string) ([][]string, error) {
+	var result [][]string
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rooms := strings.Split(line, "-")
+		for _, name := range rooms {
+			if name == "" || strings.ContainsAny(name, " \t") {
+				return nil, fmt.Errorf("line %d: invalid path '%v'", i+1, line)
+			}
+		}
+		result = append(result, rooms)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("there are no paths")
+	}
+	return result, nil
+}
+
+// setPaths - validates paths of options and prepares them as paths which ants take
+func (a *anthive) setPaths(o *Options) error {
+	if len(a.Colonies) > 0 {
+		return errors.New("paths can't be used with colonies")
+	} else if a.Foraging {
+		return errors.New("paths can't be used with foraging")
+	} else if len(o.Waypoints) > 0 {
+		return errors.New("paths can't be used with waypoints")
+	}
+	owner := make(map[*room]int)      // number of path by its rooms
+	tunnels := make(map[[2]*room]int) // number of path by its tunnels (edge-disjoint mode)
+	for i, rooms := range o.Paths {
+		path, err := a.pathOf(rooms)
+		if err != nil {
+			return fmt.Errorf("path %d: %v", i+1, err)
+		}
+		for prev, node := path.Start, path.Front; node != nil; prev, node = node.Room, node.Next {
+			key := tunnelKey(prev, node.Room)
+			if !o.Edges && node.Next == nil {
+				break
+			} else if !o.Edges {
+				if other, ok := owner[node.Room]; ok {
+					return fmt.Errorf("path %d: room '%v' belongs to path %d, paths must be vertex-disjoint", i+1, node.Room.Name, other)
+				}
+				owner[node.Room] = i + 1
+			} else if other, ok := tunnels[key]; ok {
+				return fmt.Errorf("path %d: tunnel '%v-%v' belongs to path %d, paths must be edge-disjoint", i+1, prev.Name, node.Room.Name, other)
+			} else {
+				tunnels[key] = i + 1
+			}
+		}
+		for j, other := range o.pinned {
+			if path.Len == 1 && other.Len == 1 && path.Start == other.Start && path.Back.Room == other.Back.Room {
+				return fmt.Errorf("path %d repeats path %d", i+1, j+1)
+			}
+		}
+		o.pinned = append(o.pinned, path)
+	}
+	return nil
+}
+
+// pathOf - returns path by names of its rooms, error if it isn't simple path from start room to end room by tunnels
+func (a *anthive) pathOf(rooms []string) (*list, error) {
+	if len(rooms) < 2 {
+		return nil, errors.New("path must have start and end rooms")
+	}
+	visited := make(map[string]bool)
+	var path *list
+	for i, name := range rooms {
+		r := a.Rooms[name]
+		if r == nil {
+			return nil, fmt.Errorf("unknown room '%v'", name)
+		} else if visited[name] {
+			return nil, fmt.Errorf("room '%v' is passed twice, path must be simple", name)
+		} else if i == 0 && !a.isStart(name) {
+			return nil, fmt.Errorf("room '%v' isn't start room", name)
+		} else if i == len(rooms)-1 && !a.isEnd(name) {
+			return nil, fmt.Errorf("room '%v' isn't end room", name)
+		} else if i > 0 && i < len(rooms)-1 && (a.isStart(name) || a.isEnd(name)) {
+			return nil, fmt.Errorf("path goes through start or end room '%v'", name)
+		}
+		visited[name] = true
+		if i == 0 {
+			path = &list{Start: r}
+			continue
+		}
+		prev := a.Rooms[rooms[i-1]]
+		if !prev.canGo(r) {
+			return nil, fmt.Errorf("ants can't go from room '%v' to room '%v'", prev.Name, name)
+		}
+		path.Dist += prev.length(r)
+		path.PushBack(r)
+		path.Back.Dist = path.Dist
+	}
+	// all ants go together by path without rooms and limit
+	_, limited := path.Start.Capacities[path.Back.Room]
+	path.Direct = path.Len == 1 && !limited
+	return path, nil
+}
+
+// matchPinned - sends ants by the given paths, if the solver fills the rest then other paths are added by min cost flow of free places
+func (a *anthive) matchPinned(o *Options) error {
+	groups := make(map[string][]*list)
+	used := &usage{Rooms: make(map[*room]int), Tunnels: make(map[[2]*room]int)}
+	for _, path := range o.pinned {
+		group := a.groupOf(path.Start.Name)
+		groups[group] = append(groups[group], path)
+		used.add(path)
+	}
+	var paths []*list
+	steps := 0
+	if !o.Fill {
+		for _, start := range a.Starts {
+			if group := a.groupOf(start); len(groups[group]) == 0 && (group != "" || a.freeAnts() > 0) {
+				return fmt.Errorf("ants of start room '%v' haven't path", start)
+			}
+		}
+		paths, steps = a.scheduleAnts(o, groups)
+	} else {
+		n, source, sink := a.buildNetwork(o, nil, used)
+		for flow := 0; ; flow++ {
+			curGroups := a.groupPaths(n.paths(a))
+			for group, pinned := range groups {
+				curGroups[group] = append(curGroups[group], otherPaths(pinned, curGroups[group])...)
+			}
+			if curPaths, curSteps := a.scheduleAnts(o, curGroups); curSteps > 0 && (steps == 0 || curSteps < steps) {
+				paths, steps = curPaths, curSteps
+			}
+			if flow >= a.AntsCount || !n.augment(source, sink) {
+				break
+			}
+		}
+	}
+	if steps == 0 {
+		return errors.New("path not found")
+	}
+	a.StepsCount = steps
+	a.Result.Paths = paths
+	a.Result.Map = a.Map()
+	return nil
+}
+
+// otherPaths - returns copies of paths without paths without rooms which are in other paths
+// (paths with rooms are disjoint with free places). Timetable saves departures in paths, so every timetable takes new copies
+func otherPaths(paths, other []*list) []*list {
+	var result []*list
+	for _, path := range paths {
+		found := false
+		for _, o := range other {
+			found = found || path.Direct && o.Direct && path.Start == o.Start && path.Back.Room == o.Back.Room
+		}
+		if !found {
+			copied := *path
+			result = append(result, &copied)
+		}
+	}
+	return result
+}
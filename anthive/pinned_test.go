@@ -0,0 +1,101 @@
+package anthive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		paths   string // paths separated by spaces
+		err     string
+	}{
+		{"paths with comments", "# paths\n1-3-4-cool\n\n1-2-5-6-cool\n", "1-3-4-cool 1-2-5-6-cool", ""},
+		{"empty room", "1-3--cool\n", "", "line 1: invalid path '1-3--cool'"},
+		{"room with space", "1-3-4-cool\n1-2 5-cool\n", "", "line 2: invalid path '1-2 5-cool'"},
+		{"only comments", "# paths\n", "", "there are no paths"},
+	}
+	for _, test := range tests {
+		paths, err := ParsePaths(test.content)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var lines []string
+		for _, rooms := range paths {
+			lines = append(lines, strings.Join(rooms, "-"))
+		}
+		if strings.Join(lines, " ") != test.paths {
+			t.Errorf("%s: paths %v, want %v", test.name, lines, test.paths)
+		}
+	}
+}
+
+func TestSetPathsErrors(t *testing.T) {
+	direct := "2\n##start\ns 0 0\n##end\ne 1 0\ns-e\n"
+	tests := []struct {
+		name    string
+		content string
+		options Options
+		err     string
+	}{
+		{"unknown room", exampleMap, Options{Paths: [][]string{{"1", "x", "cool"}}}, "path 1: unknown room 'x'"},
+		{"path from other room", exampleMap, Options{Paths: [][]string{{"3", "4", "cool"}}}, "path 1: room '3' isn't start room"},
+		{"path to other room", exampleMap, Options{Paths: [][]string{{"1", "3", "4"}}}, "path 1: room '4' isn't end room"},
+		{"room is passed twice", exampleMap, Options{Paths: [][]string{{"1", "3", "4", "3", "cool"}}}, "path 1: room '3' is passed twice, path must be simple"},
+		{"rooms without tunnel", exampleMap, Options{Paths: [][]string{{"1", "4", "cool"}}}, "path 1: ants can't go from room '1' to room '4'"},
+		{"paths share room", exampleMap, Options{Paths: [][]string{{"1", "3", "4", "cool"}, {"1", "2", "4", "cool"}}},
+			"path 2: room '4' belongs to path 1, paths must be vertex-disjoint"},
+		{"path is repeated", direct, Options{Paths: [][]string{{"s", "e"}, {"s", "e"}}}, "path 2 repeats path 1"},
+		{"paths with waypoints", exampleMap, Options{Paths: [][]string{{"1", "3", "4", "cool"}}, Waypoints: []string{"4"}}, "paths can't be used with waypoints"},
+	}
+	for _, test := range tests {
+		a := readTest(t, test.name, test.content)
+		o := test.options
+		if err := a.SetOptions(&o); err == nil || err.Error() != test.err {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestMatchPinned(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths [][]string
+		fill  bool
+		turns int
+	}{
+		{"ants take exactly the path", [][]string{{"1", "3", "4", "cool"}}, false, 5},
+		{"ants take exactly the paths", [][]string{{"1", "2", "7", "4", "cool"}, {"1", "3", "5", "6", "cool"}}, false, 5},
+		{"solver adds path", [][]string{{"1", "3", "4", "cool"}}, true, 4},
+	}
+	for _, test := range tests {
+		_, moves := solveTest(t, test.name, exampleMap, &Options{Paths: test.paths, Fill: test.fill}, test.turns)
+		// every ant takes one of the given paths unless the solver adds paths
+		routes := make(map[string]bool)
+		for _, rooms := range test.paths {
+			routes[strings.Join(rooms[1:], "-")] = true
+		}
+		taken := make(map[int][]string)
+		for _, step := range moves {
+			for _, move := range step {
+				taken[move.Ant] = append(taken[move.Ant], move.Room)
+			}
+		}
+		given := 0
+		for _, rooms := range taken {
+			if routes[strings.Join(rooms, "-")] {
+				given++
+			}
+		}
+		if !test.fill && given != len(taken) || test.fill && (given == 0 || given == len(taken)) {
+			t.Errorf("%s: %d of %d ants take given paths", test.name, given, len(taken))
+		}
+	}
+}
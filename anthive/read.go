@@ -0,0 +1,66 @@
+package anthive
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Formats of input
+const (
+	FORMAT_AUTO = "auto" // detect by content
+	FORMAT_MAP  = "map"  // classic lem-in format
+	FORMAT_DOT  = "dot"  // Graphviz undirected graph
+	FORMAT_JSON = "json" // JSON document with ants, rooms and tunnels
+)
+
+// DetectFormat - returns format of content by first meaningful line
+func DetectFormat(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return FORMAT_JSON
+		}
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "graph") || strings.HasPrefix(lower, "digraph") || strings.HasPrefix(lower, "strict") {
+			return FORMAT_DOT
+		}
+		return FORMAT_MAP
+	}
+	return FORMAT_MAP
+}
+
+// ReadAnthive - builds anthive from content in format by default rules. Returns an error if content is invalid
+func ReadAnthive(content, format string) (*anthive, error) {
+	return ReadAnthiveByRules(content, format, DefaultRules)
+}
+
+// ReadAnthiveByRules - builds anthive from content in format by rules of the lem-in variant. Returns an error if content is invalid
+func ReadAnthiveByRules(content, format string, rules Rules) (*anthive, error) {
+	if format == FORMAT_AUTO || format == "" {
+		format = DetectFormat(content)
+	}
+	switch format {
+	case FORMAT_MAP:
+		a := Createanthive()
+		a.Rules = rules
+		for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+			err := a.ReadDataFromLine(strings.TrimSuffix(line, "\r"))
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := a.ValidateByFieldInfo()
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case FORMAT_DOT:
+		return readDOT(content, rules)
+	case FORMAT_JSON:
+		return readJSON(content, rules)
+	}
+	return nil, fmt.Errorf("unknown format '%v'", format)
+}
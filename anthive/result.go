@@ -20,44 +20,192 @@ func pathsOfListToSlice(paths []*list) [][]*room {
 	return result
 }
 
-// WriteResult - write result with writer
-func (r *Result) WriteResult(w io.Writer) {
-	sort.Slice(r.Paths, func(i, j int) bool { return r.Paths[i].Len < r.Paths[j].Len })
-	steps, antsForEachPath := calcSteps(r.AntsCount, r.Paths)
-	if steps == 1 {
-		roomName := r.Paths[0].Front.Room.Name
-		for ant := 1; ant <= antsForEachPath[0]; ant++ {
-			fmt.Fprintf(w, "L%d-%s ", ant, roomName)
-		}
-		fmt.Fprintln(w)
-	} else {
-		paths := pathsOfListToSlice(r.Paths)
-		a, b := &antQueue{}, &antQueue{}
-		antNum := 1
-		for i := 1; i <= steps; i++ {
-			cur := a.Dequeue()
-			for cur != nil {
-				// fmt.Fprintf(output, "L%d-%s ", cur.Num, result.Paths[cur.Path][cur.Pos].Name)
-				w.Write([]byte(fmt.Sprintf("L%d-%s ", cur.Num, paths[cur.Path][cur.Pos].Name)))
-				cur.Pos++
-				if cur.Pos < len(paths[cur.Path]) {
-					b.EnqueueAnt(cur)
+// Move - ant moves into the room
+type Move struct {
+	Ant  int // number of ant, ants of colonies are numbered one colony after another
+	Room string
+	Name string // name of ant of colony in result line without L: <colony>.<n>, empty for map without colonies
+	Back bool   // move of the way back from end room to start room (foraging)
+}
+
+// String - move in format of result line: L<ant>-<room> or L<colony>.<n>-<room>, move of the way back is L<ant><-<room>
+func (m Move) String() string {
+	back := ""
+	if m.Back {
+		back = "<"
+	}
+	if m.Name != "" {
+		return fmt.Sprintf("L%s%s-%s", m.Name, back, m.Room)
+	}
+	return fmt.Sprintf("L%d%s-%s", m.Ant, back, m.Room)
+}
+
+// sortPaths - sorting paths by length (turns from start to end). Stable, so order of paths doesn't change between calls
+func (r *Result) sortPaths() {
+	sort.SliceStable(r.Paths, func(i, j int) bool { return r.Paths[i].Dist < r.Paths[j].Dist })
+}
+
+// Routes - returns room names of each path (without start room), sorted by length
+func (r *Result) Routes() [][]string {
+	r.sortPaths()
+	paths := pathsOfListToSlice(r.Paths)
+	result := make([][]string, len(paths))
+	for i, path := range paths {
+		result[i] = make([]string, len(path))
+		for j, room := range path {
+			result[i][j] = room.Name
+		}
+	}
+	return result
+}
+
+// Starts - returns start room of each path of Routes
+func (r *Result) Starts() []string {
+	r.sortPaths()
+	result := make([]string, len(r.Paths))
+	for i, path := range r.Paths {
+		if path.Start != nil {
+			result[i] = path.Start.Name
+		} else if r.Map != nil {
+			result[i] = r.Map.Start
+		}
+	}
+	return result
+}
+
+// AntsPerPath - returns count of ants sent by each path of Routes
+func (r *Result) AntsPerPath() []int {
+	r.sortPaths()
+	_, antsForEachPath := r.distribution()
+	return antsForEachPath
+}
+
+// Turns - returns count of turns of the result
+func (r *Result) Turns() int {
+	r.sortPaths()
+	steps, _ := r.distribution()
+	return steps
+}
+
+// distribution - returns count of steps and count of ants for each path.
+// Ants of start room with count of ants go only by paths from this room, other ants share other paths.
+// If paths have steps of departures, then every path takes ant on each of them
+func (r *Result) distribution() (int, []int) {
+	if len(r.Paths) > 0 && r.Paths[0].Departures != nil {
+		steps, result := 0, make([]int, len(r.Paths))
+		for i, path := range r.Paths {
+			result[i] = len(path.Departures)
+			if last := len(path.Departures) - 1; last >= 0 && path.Departures[last]+path.Dist > steps {
+				steps = path.Departures[last] + path.Dist
+			}
+		}
+		return steps, result
+	}
+	groups := make(map[string][]int) // indexes of paths by start room with count of ants
+	for i, path := range r.Paths {
+		group := ""
+		if path.Start != nil && r.Map != nil {
+			if _, ok := r.Map.StartAnts[path.Start.Name]; ok {
+				group = path.Start.Name
+			}
+		}
+		groups[group] = append(groups[group], i)
+	}
+	steps, result := 0, make([]int, len(r.Paths))
+	for group, indexes := range groups {
+		ants := r.AntsCount
+		if group != "" {
+			ants = r.Map.StartAnts[group]
+		} else if r.Map != nil {
+			for _, count := range r.Map.StartAnts {
+				ants -= count
+			}
+		}
+		paths := make([]*list, len(indexes))
+		for i, index := range indexes {
+			paths[i] = r.Paths[index]
+		}
+		groupSteps, antsForEachPath := calcSteps(ants, paths)
+		for i, index := range indexes {
+			result[index] = antsForEachPath[i]
+		}
+		if groupSteps > steps {
+			steps = groupSteps
+		}
+	}
+	return steps, result
+}
+
+// Moves - returns moves of ants for every step.
+// Every step each path takes next ant from start, ant appears in room when it passes path to the room.
+// Ants of colonies are numbered in their colonies
+func (r *Result) Moves() [][]Move {
+	r.sortPaths()
+	steps, antsForEachPath := r.distribution()
+	result := make([][]Move, steps)
+	sent := 0
+	// number of the first ant and count of sent ants of colony by start room
+	first, sentByStart := make(map[string]int), make(map[string]int)
+	if r.Map != nil {
+		ant := 1
+		for _, c := range r.Map.Colonies {
+			first[c.Start] = ant
+			ant += c.Ants
+		}
+	}
+	for i := 0; sent < r.AntsCount; i++ {
+		for j, path := range r.Paths {
+			count := 0
+			if path.Departures != nil {
+				for _, departure := range path.Departures {
+					if departure == i {
+						count++
+					}
+				}
+			} else if antsForEachPath[j] > 0 {
+				count = 1
+				// all ants go together by path without rooms
+				if path.Direct {
+					count = antsForEachPath[j]
 				}
-				cur = a.Dequeue()
 			}
-			for j, v := range antsForEachPath {
-				if v > 0 {
-					// fmt.Fprintf(output, "L%d-%s ", antNum, result.Paths[j][0].Name)
-					w.Write([]byte(fmt.Sprintf("L%d-%s ", antNum, paths[j][0].Name)))
-					antsForEachPath[j]--
-					b.Enqueue(antNum, j, 1)
-					antNum++
+			for ; count > 0; count-- {
+				sent++
+				ant, name := sent, ""
+				if path.Start != nil {
+					if number, ok := first[path.Start.Name]; ok {
+						ant = number + sentByStart[path.Start.Name]
+						sentByStart[path.Start.Name]++
+						name = r.Map.AntName(ant)[1:]
+					}
 				}
+				// round trip goes back after end room
+				back := false
+				for node := path.Front; node != nil; node = node.Next {
+					step := i + node.Dist - 1
+					result[step] = append(result[step], Move{Ant: ant, Room: node.Room.Name, Name: name, Back: back})
+					back = back || r.Map != nil && r.Map.IsEnd(node.Room.Name)
+				}
+				antsForEachPath[j]--
 			}
-			t := a
-			a = b
-			b = t
-			fmt.Fprintln(w)
 		}
 	}
+	for _, step := range result {
+		sort.Slice(step, func(i, j int) bool { return step[i].Ant < step[j].Ant })
+	}
+	return result
+}
+
+// WriteMoves - write moves of every step with writer by default rules
+func WriteMoves(w io.Writer, moves [][]Move) {
+	DefaultRules.WriteMoves(w, moves)
+}
+
+// WriteResult - write result with writer by rules of its map
+func (r *Result) WriteResult(w io.Writer) {
+	rules := DefaultRules
+	if r.Map != nil {
+		rules = r.Map.Rules
+	}
+	rules.WriteMoves(w, r.Moves())
 }
@@ -0,0 +1,135 @@
+package anthive
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Lem-in variants disagree on details of the map and moves. Rules of the variant are used by the parser (all formats),
+// by writing of moves and by checking of moves, so the solver writes moves which pass its own checking
+
+// Presets of rules
+const (
+	RULES_DEFAULT   = "default"   // rules of this project
+	RULES_STRICT_42 = "strict-42" // rules of 42 school subject
+	RULES_LENIENT   = "lenient"   // everything which can be read is allowed
+	RULES_CUSTOM    = "custom"    // allowed rules are listed (ParseRules)
+)
+
+// Names of rules which can be allowed
+const (
+	RULE_DUPLICATE_LINKS  = "duplicate-links"  // the same tunnel can be written several times
+	RULE_UNKNOWN_COMMANDS = "unknown-commands" // unknown ## commands are ignored
+	RULE_TRAILING_SPACES  = "trailing-spaces"  // lines of moves end with space
+	RULE_HASH_NAMES       = "hash-names"       // room names can start with '#'
+	RULE_NEGATIVE_COORDS  = "negative-coords"  // room coordinates can be negative
+)
+
+// Rules - details of the lem-in variant, true means that it's allowed
+type Rules struct {
+	Name            string // preset of rules or RULES_CUSTOM
+	DuplicateLinks  bool   // the same tunnel (with the same length and direction) is ignored, else it's an error
+	UnknownCommands bool   // unknown ## commands are ignored as comments, else they are errors
+	TrailingSpaces  bool   // moves are written with space after every move, else lines of moves can't end with spaces
+	HashNames       bool   // room names can start with '#' (but not with "##"), else such lines are comments
+	NegativeCoords  bool   // room coordinates can be negative
+}
+
+// DefaultRules - rules of this project, they are used if rules aren't set
+var DefaultRules = Rules{Name: RULES_DEFAULT, DuplicateLinks: true, TrailingSpaces: true, NegativeCoords: true}
+
+// Presets - rules of presets in order of help
+var Presets = []Rules{
+	DefaultRules,
+	{Name: RULES_STRICT_42, UnknownCommands: true},
+	{Name: RULES_LENIENT, DuplicateLinks: true, UnknownCommands: true, TrailingSpaces: true, HashNames: true, NegativeCoords: true},
+}
+
+// commands - known ## commands of the map
+var commands = []string{"##start", "##end", "##capacity", "##colony", "##arrivals", "##foraging", "##closed"}
+
+// Rules for Rules:
+// Value is name of preset or custom rules: names of allowed rules separated by ',' (other rules aren't allowed).
+// Room names can't start with "##" by any rules, because such lines are commands
+
+// ParseRules - returns rules of preset or custom rules by value: preset name or allowed rules separated by ','
+func ParseRules(value string) (Rules, error) {
+	for _, preset := range Presets {
+		if value == preset.Name {
+			return preset, nil
+		}
+	}
+	rules := Rules{Name: RULES_CUSTOM}
+	for _, name := range strings.Split(value, ",") {
+		switch name {
+		case RULE_DUPLICATE_LINKS:
+			rules.DuplicateLinks = true
+		case RULE_UNKNOWN_COMMANDS:
+			rules.UnknownCommands = true
+		case RULE_TRAILING_SPACES:
+			rules.TrailingSpaces = true
+		case RULE_HASH_NAMES:
+			rules.HashNames = true
+		case RULE_NEGATIVE_COORDS:
+			rules.NegativeCoords = true
+		default:
+			return Rules{}, fmt.Errorf("unknown rules '%v'", name)
+		}
+	}
+	return rules, nil
+}
+
+// isUnknownCommand - returns true if line is ## command which isn't known
+func isUnknownCommand(line string) bool {
+	if !strings.HasPrefix(line, "##") {
+		return false
+	}
+	name := strings.Split(line, " ")[0]
+	for _, command := range commands {
+		if name == command {
+			return false
+		}
+	}
+	return true
+}
+
+// isHashLine - returns true if line started with '#' is room or tunnel with room name started with '#' (Rules.HashNames)
+func (a *anthive) isHashLine(line string) bool {
+	if !a.Rules.HashNames || strings.HasPrefix(line, "##") || a.FieldInfo.MODE == FIELD_ANTS {
+		return false
+	}
+	fields := strings.Split(line, " ")
+	if a.FieldInfo.MODE == FIELD_ROOMS && len(fields) == 3 && !strings.ContainsAny(fields[0], "->") {
+		_, errX := strconv.Atoi(fields[1])
+		_, errY := strconv.Atoi(fields[2])
+		return errX == nil && errY == nil
+	}
+	names := strings.FieldsFunc(fields[0], func(r rune) bool { return r == '-' || r == '>' })
+	return len(names) == 2 && a.Rooms[names[0]] != nil && a.Rooms[names[1]] != nil
+}
+
+// ParseMoves - reads moves of one step from result line by the rules
+func (r Rules) ParseMoves(line string) ([]Move, error) {
+	if !r.TrailingSpaces && strings.TrimRight(line, " \t") != line {
+		return nil, fmt.Errorf("line of moves can't end with spaces: '%v'", line)
+	}
+	return ParseMoves(line)
+}
+
+// WriteMoves - writes moves of every step by the rules: with space after every move or separated by spaces
+func (r Rules) WriteMoves(w io.Writer, moves [][]Move) {
+	for _, step := range moves {
+		for i, move := range step {
+			if r.TrailingSpaces {
+				fmt.Fprintf(w, "%v ", move)
+			} else if i > 0 {
+				fmt.Fprintf(w, " %v", move)
+			} else {
+				fmt.Fprint(w, move)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
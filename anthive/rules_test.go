@@ -0,0 +1,125 @@
+package anthive
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		value string
+		rules Rules
+		err   string
+	}{
+		{RULES_DEFAULT, DefaultRules, ""},
+		{RULES_STRICT_42, Rules{Name: RULES_STRICT_42, UnknownCommands: true}, ""},
+		{RULES_LENIENT, Rules{Name: RULES_LENIENT, DuplicateLinks: true, UnknownCommands: true, TrailingSpaces: true, HashNames: true, NegativeCoords: true}, ""},
+		{"hash-names,negative-coords", Rules{Name: RULES_CUSTOM, HashNames: true, NegativeCoords: true}, ""},
+		{"trailing-spaces", Rules{Name: RULES_CUSTOM, TrailingSpaces: true}, ""},
+		{"duplicate-links,strict", Rules{}, "unknown rules 'strict'"},
+		{"", Rules{}, "unknown rules ''"},
+	}
+	for _, test := range tests {
+		rules, err := ParseRules(test.value)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("'%s': error %v, want '%v'", test.value, err, test.err)
+		} else if rules != test.rules {
+			t.Errorf("'%s': rules %+v, want %+v", test.value, rules, test.rules)
+		}
+	}
+}
+
+func TestReadAnthiveByRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		room    string             // room which is read only if the rule is allowed, "" means that map isn't read
+		allowed func(r Rules) bool // the rule of the case
+	}{
+		{"duplicate tunnel", "2\n##start\ns 0 0\n##end\ne 1 0\ns-e\ns-e\n", "",
+			func(r Rules) bool { return r.DuplicateLinks }},
+		{"unknown command", "2\n##start\ns 0 0\n##unknown\n##end\ne 1 0\ns-e\n", "",
+			func(r Rules) bool { return r.UnknownCommands }},
+		{"room name with hash", "2\n##start\ns 0 0\n#a 1 0\n##end\ne 2 0\n#a-s\n#a-e\ns-e\n", "#a",
+			func(r Rules) bool { return r.HashNames }},
+		{"negative coordinates", "2\n##start\ns -1 -2\n##end\ne 1 0\ns-e\n", "",
+			func(r Rules) bool { return r.NegativeCoords }},
+	}
+	for _, test := range tests {
+		for _, rules := range Presets {
+			name := fmt.Sprintf("%s, %s", test.name, rules.Name)
+			a, err := ReadAnthiveByRules(test.content, FORMAT_MAP, rules)
+			if test.room != "" {
+				// line with hash is a comment if the rule isn't allowed
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+				} else if (a.Rooms[test.room] != nil) != test.allowed(rules) {
+					t.Errorf("%s: room '%s' is read %v, want %v", name, test.room, a.Rooms[test.room] != nil, test.allowed(rules))
+				}
+			} else if (err == nil) != test.allowed(rules) {
+				t.Errorf("%s: error %v, allowed %v", name, err, test.allowed(rules))
+			}
+		}
+	}
+}
+
+func TestRulesMoves(t *testing.T) {
+	moves := [][]Move{{{Ant: 1, Room: "a"}, {Ant: 2, Room: "b"}}, {{Ant: 1, Room: "e"}}}
+	for _, rules := range Presets {
+		var buf bytes.Buffer
+		rules.WriteMoves(&buf, moves)
+		want := "L1-a L2-b\nL1-e\n"
+		if rules.TrailingSpaces {
+			want = "L1-a L2-b \nL1-e \n"
+		}
+		if buf.String() != want {
+			t.Errorf("%s: moves are written as %q, want %q", rules.Name, buf.String(), want)
+		}
+		// written moves are read by the same rules
+		for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			step, err := rules.ParseMoves(line)
+			if err != nil || fmt.Sprint(step) != fmt.Sprint(moves[i]) {
+				t.Errorf("%s: line %q is read as %v, error %v", rules.Name, line, step, err)
+			}
+		}
+		if _, err := rules.ParseMoves("L1-a L2-b "); (err == nil) != rules.TrailingSpaces {
+			t.Errorf("%s: line with trailing space, error %v", rules.Name, err)
+		}
+	}
+}
+
+func TestMatchRules(t *testing.T) {
+	for _, rules := range Presets {
+		a, err := ReadAnthiveByRules(exampleMap, FORMAT_MAP, rules)
+		if err != nil {
+			t.Errorf("%s: %v", rules.Name, err)
+			continue
+		}
+		if err = a.Match(nil); err != nil {
+			t.Errorf("%s: %v", rules.Name, err)
+			continue
+		}
+		if a.Result.Map.Rules != rules {
+			t.Errorf("%s: map has rules %+v", rules.Name, a.Result.Map.Rules)
+		}
+		// result is written by the rules of the map and its lines are read and checked by the same rules
+		var buf bytes.Buffer
+		a.Result.WriteResult(&buf)
+		var moves [][]Move
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			if strings.HasSuffix(line, " ") != rules.TrailingSpaces {
+				t.Errorf("%s: line %q of result", rules.Name, line)
+			}
+			step, err := a.Result.Map.Rules.ParseMoves(line)
+			if err != nil {
+				t.Errorf("%s: %v", rules.Name, err)
+			}
+			moves = append(moves, step)
+		}
+		if err = a.Result.Map.Verify(moves); err != nil {
+			t.Errorf("%s: %v", rules.Name, err)
+		}
+	}
+}
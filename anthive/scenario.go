@@ -0,0 +1,538 @@
+package anthive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scenario of events (robustness of the map): the map is solved, then tunnels collapse and rooms fill in by events.
+// Collapsed tunnel and filled room can't be used from the turn of event till the end.
+// On the turn of event moves of previous turns are kept and remaining ants are planned again from their places:
+// ants which are inside the collapsed tunnel, or in the filled room, or go into it are trapped,
+// ants inside other tunnels arrive into their rooms. Ants between start and end rooms go one by one
+// (the nearest to ends first) by the earliest paths in time, ants which can't reach ends stay in their rooms (stranded).
+// Then ants from start rooms are sent by timetable by paths of min cost flow of the remaining anthive.
+// Plan isn't changed if events don't touch its ants, rooms and tunnels
+
+// Event - tunnel between rooms collapses or room fills in on the turn
+type Event struct {
+	Turn     int
+	Room     string // filled room, empty if tunnel collapses
+	From, To string // rooms of collapsed tunnel
+}
+
+// String - description of event: tunnel 'a-b' collapses or room 'c' fills in
+func (e Event) String() string {
+	if e.Room != "" {
+		return fmt.Sprintf("room '%v' fills in", e.Room)
+	}
+	return fmt.Sprintf("tunnel '%v-%v' collapses", e.From, e.To)
+}
+
+// ParseEvents - parses events, one event in line: <turn> collapse <room>-<room> or <turn> fill <room>.
+// Empty lines and comments are skipped, events are sorted by turns
+func ParseEvents(content string) ([]Event, error) {
+	var result []Event
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: event must be '<turn> collapse <room>-<room>' or '<turn> fill <room>'", i+1)
+		}
+		turn, err := strconv.Atoi(fields[0])
+		if err != nil || turn < 1 {
+			return nil, fmt.Errorf("line %d: invalid turn '%v'", i+1, fields[0])
+		}
+		e := Event{Turn: turn}
+		switch fields[1] {
+		case "collapse":
+			rooms := strings.Split(fields[2], "-")
+			if len(rooms) != 2 || rooms[0] == "" || rooms[1] == "" {
+				return nil, fmt.Errorf("line %d: invalid tunnel '%v'", i+1, fields[2])
+			}
+			e.From, e.To = rooms[0], rooms[1]
+		case "fill":
+			e.Room = fields[2]
+		default:
+			return nil, fmt.Errorf("line %d: unknown event '%v'", i+1, fields[1])
+		}
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Turn < result[j].Turn })
+	return result, nil
+}
+
+// Plan - moves of ants which are planned on the turn
+type Plan struct {
+	Turn     int      // the first turn of the plan, 1 for the first plan
+	Events   []Event  // events of the turn, nil for the first plan
+	Changed  bool     // false if events don't touch the previous plan
+	Moves    [][]Move // moves from the turn, nil if plan isn't changed
+	Steps    int      // count of turns from the first turn till the end of the plan
+	Trapped  []int    // ants which are trapped by events of the turn
+	Stranded []int    // ants which can't reach end rooms
+}
+
+// Scenario - the first plan of the map and plans after events
+type Scenario struct {
+	Map   *Map // map before events
+	Plans []Plan
+	Moves [][]Move // moves of all turns by the last plan
+}
+
+// Reached - returns count of ants which reach end rooms by the last plan
+func (s *Scenario) Reached() int {
+	result := s.Map.AntsCount
+	for _, plan := range s.Plans {
+		result -= len(plan.Trapped)
+	}
+	return result - len(s.Plans[len(s.Plans)-1].Stranded)
+}
+
+// WriteScenario - writes every plan with its events and count of turns, then count of turns and ants of the last plan
+func (s *Scenario) WriteScenario(w io.Writer) {
+	for _, plan := range s.Plans {
+		if plan.Events == nil {
+			fmt.Fprintf(w, "# plan: %d turns\n", plan.Steps)
+			s.Map.Rules.WriteMoves(w, plan.Moves)
+			continue
+		}
+		for _, e := range plan.Events {
+			fmt.Fprintf(w, "# turn %d: %v\n", plan.Turn, e)
+		}
+		if !plan.Changed {
+			fmt.Fprintln(w, "# plan isn't changed")
+			continue
+		}
+		fmt.Fprintf(w, "# re-plan from turn %d: %d turns%v%v\n", plan.Turn, plan.Steps,
+			s.antNames(", trapped", plan.Trapped), s.antNames(", stranded", plan.Stranded))
+		s.Map.Rules.WriteMoves(w, plan.Moves)
+	}
+	fmt.Fprintf(w, "# result: %d turns, %d of %d ants reached end\n", len(s.Moves), s.Reached(), s.Map.AntsCount)
+}
+
+// antNames - returns title and names of ants: ", trapped: L1 L2", empty string if there are no ants
+func (s *Scenario) antNames(title string, ants []int) string {
+	if len(ants) == 0 {
+		return ""
+	}
+	names := make([]string, len(ants))
+	for i, ant := range ants {
+		names[i] = s.Map.AntName(ant)
+	}
+	return fmt.Sprintf("%v: %v", title, strings.Join(names, " "))
+}
+
+// Simulate - applies events to the solved anthive (after Match with the same options, nil means default options)
+// and plans remaining ants again on turns of events
+func (a *anthive) Simulate(events []Event, o *Options) (*Scenario, error) {
+	if o == nil {
+		o = &Options{}
+	}
+	if a.Result.Map == nil {
+		return nil, errors.New("anthive isn't solved")
+	} else if a.Foraging {
+		return nil, errors.New("scenario can't be simulated for foraging")
+	} else if len(o.Waypoints) > 0 {
+		return nil, errors.New("scenario can't be simulated with waypoints")
+	}
+	events = append([]Event{}, events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Turn < events[j].Turn })
+	if err := a.validateEvents(events); err != nil {
+		return nil, err
+	}
+	m := a.Result.Map
+	moves := a.Result.Moves()
+	s := &Scenario{Map: m, Plans: []Plan{{Turn: 1, Changed: true, Moves: moves, Steps: len(moves)}}}
+	starts := m.AntStarts(moves)
+	lost := make(map[int]bool) // trapped ants
+	var stranded []int
+	for i := 0; i < len(events); {
+		plan := Plan{Turn: events[i].Turn, Stranded: stranded}
+		collapsed, filled := make(map[[2]string]bool), make(map[string]bool)
+		for ; i < len(events) && events[i].Turn == plan.Turn; i++ {
+			e := events[i]
+			plan.Events = append(plan.Events, e)
+			if e.Room != "" {
+				filled[e.Room] = true
+				a.fillRoom(a.Rooms[e.Room])
+			} else {
+				collapsed[tunnelNames(e.From, e.To)] = true
+				a.removeLink(a.Rooms[e.From], a.Rooms[e.To])
+			}
+		}
+		places := m.antPlaces(moves, starts, plan.Turn)
+		for ant := 1; ant <= m.AntsCount; ant++ {
+			p := places[ant]
+			if !lost[ant] && (filled[p.Room] || p.From != "" && collapsed[tunnelNames(p.From, p.Room)]) {
+				lost[ant] = true
+				plan.Trapped = append(plan.Trapped, ant)
+			}
+		}
+		if len(plan.Trapped) > 0 || touches(moves, places, lost, plan.Turn, collapsed, filled) {
+			moves, stranded = a.replan(o, m, moves, places, starts, lost, plan.Turn)
+			plan.Changed, plan.Stranded = true, stranded
+			plan.Moves = [][]Move{}
+			if len(moves) >= plan.Turn {
+				plan.Moves = moves[plan.Turn-1:]
+			}
+		}
+		plan.Steps = len(moves)
+		s.Plans = append(s.Plans, plan)
+	}
+	s.Moves = moves
+	return s, nil
+}
+
+// validateEvents - returns an error if event has unknown room or tunnel, or start or end room fills in
+func (a *anthive) validateEvents(events []Event) error {
+	for _, e := range events {
+		var err error
+		if e.Turn < 1 {
+			err = errors.New("invalid turn")
+		} else if e.Room != "" {
+			if a.Rooms[e.Room] == nil {
+				err = errors.New("unknown room")
+			} else if a.isStart(e.Room) || a.isEnd(e.Room) {
+				err = errors.New("start and end rooms can't fill in")
+			}
+		} else if a.Rooms[e.From] == nil || a.Rooms[e.To] == nil {
+			err = errors.New("tunnel contains unknown room")
+		} else if _, ok := a.Rooms[e.From].Paths[a.Rooms[e.To]]; !ok {
+			err = errors.New("rooms aren't linked")
+		}
+		if err != nil {
+			return fmt.Errorf("turn %d, %v: %v", e.Turn, e, err)
+		}
+	}
+	return nil
+}
+
+// fillRoom - removes all paths of the room
+func (a *anthive) fillRoom(r *room) {
+	var linked []*room
+	for next := range r.Paths {
+		linked = append(linked, next)
+	}
+	for _, next := range linked {
+		a.removeLink(r, next)
+	}
+}
+
+// tunnelNames - returns the same key for both directions of tunnel
+func tunnelNames(name1, name2 string) [2]string {
+	if name1 > name2 {
+		return [2]string{name2, name1}
+	}
+	return [2]string{name1, name2}
+}
+
+// antPlace - place of ant at the end of the turn before event
+type antPlace struct {
+	Room    string // room of ant, or room where ant goes by tunnel
+	From    string // room which ant left, empty if ant isn't inside tunnel
+	Arrival int    // turn of arrival into the room, 0 if ant hasn't left start room
+}
+
+// antPlaces - returns places of ants (index is number of ant) at the end of the turn before the turn.
+// starts are start rooms of ants
+func (m *Map) antPlaces(moves [][]Move, starts []string, turn int) []antPlace {
+	result := make([]antPlace, m.AntsCount+1)
+	known := make([]bool, m.AntsCount+1) // ant is inside tunnel or its next move is after the turn
+	for ant := range result {
+		result[ant].Room = starts[ant]
+	}
+	for i, step := range moves {
+		for _, move := range step {
+			p := &result[move.Ant]
+			if known[move.Ant] {
+				continue
+			} else if i+1 < turn {
+				p.Room, p.Arrival = move.Room, i+1
+				continue
+			}
+			known[move.Ant] = true
+			// ant left the room before the turn
+			if i+2-m.Length(p.Room, move.Room) < turn {
+				p.From, p.Room, p.Arrival = p.Room, move.Room, i+1
+			}
+		}
+	}
+	return result
+}
+
+// touches - returns true if ant goes through collapsed tunnel or filled room from the turn
+func touches(moves [][]Move, places []antPlace, lost map[int]bool, turn int, collapsed map[[2]string]bool, filled map[string]bool) bool {
+	cur := make(map[int]string) // rooms of ants
+	for ant, p := range places {
+		cur[ant] = p.Room
+		if p.From != "" {
+			cur[ant] = p.From
+		}
+	}
+	for i := turn - 1; i < len(moves); i++ {
+		for _, move := range moves[i] {
+			if lost[move.Ant] {
+				continue
+			} else if filled[move.Room] || collapsed[tunnelNames(cur[move.Ant], move.Room)] {
+				return true
+			}
+			cur[move.Ant] = move.Room
+		}
+	}
+	return false
+}
+
+// replan - returns moves which are kept before the turn with moves of remaining ants from their places, and stranded ants.
+// Start rooms of ants which go from start rooms are updated
+func (a *anthive) replan(o *Options, m *Map, moves [][]Move, places []antPlace, starts []string, lost map[int]bool, turn int) ([][]Move, []int) {
+	name := func(ant int) string {
+		if len(m.Colonies) > 0 {
+			return m.AntName(ant)[1:]
+		}
+		return ""
+	}
+	kept := turn - 1
+	if kept > len(moves) {
+		kept = len(moves)
+	}
+	result := make([][]Move, kept)
+	for i := range result {
+		result[i] = append([]Move{}, moves[i]...)
+	}
+	t, rt := newTimetable(), a.newRouter(o)
+	var flying []int                  // ants between start and end rooms
+	waiting := make(map[string][]int) // ants in start rooms by groups
+	for ant := 1; ant <= m.AntsCount; ant++ {
+		p := places[ant]
+		if lost[ant] {
+			continue
+		} else if p.From != "" {
+			result = addMove(result, p.Arrival-1, Move{Ant: ant, Room: p.Room, Name: name(ant)})
+		}
+		if a.isEnd(p.Room) {
+			continue
+		} else if a.isStart(p.Room) {
+			waiting[a.groupOf(p.Room)] = append(waiting[a.groupOf(p.Room)], ant)
+			continue
+		}
+		flying = append(flying, ant)
+		t.Held[a.Rooms[p.Room]]++
+	}
+	// ant can leave the room on the next step after arrival, and ants which are in rooms leave them from the turn
+	ready := func(ant int) int {
+		if places[ant].Arrival > turn-1 {
+			return places[ant].Arrival
+		}
+		return turn - 1
+	}
+	distance := func(ant int) int {
+		turns := a.routeGroup(rt, a.groupOf(starts[ant])).Turns[rt.Index[a.Rooms[places[ant].Room]]]
+		if turns < 0 {
+			return math.MaxInt32
+		}
+		return turns
+	}
+	sort.SliceStable(flying, func(i, j int) bool {
+		return distance(flying[i]) < distance(flying[j]) || distance(flying[i]) == distance(flying[j]) && ready(flying[i]) < ready(flying[j])
+	})
+	// path found by search isn't longer than all tunnels after the last taken place
+	total := 0
+	for _, l := range a.Links {
+		total += l[0].length(l[1])
+	}
+	horizon := func(step int) int {
+		return t.last() + rt.Closed + step + total + 2
+	}
+	var stranded []int
+	// ant which can't find path now can find it after other ants leave its way
+	for len(flying) > 0 {
+		var failed []int
+		for _, ant := range flying {
+			r := a.Rooms[places[ant].Room]
+			t.Held[r]--
+			path, departure := a.searchFrom(t, rt, a.groupOf(starts[ant]), []int{rt.Index[r]}, ready(ant), horizon(ready(ant)))
+			if path == nil {
+				t.Held[r]++
+				failed = append(failed, ant)
+				continue
+			}
+			for step := places[ant].Arrival - 1; step < departure; step++ {
+				if step >= turn-1 {
+					t.Rooms[r] = take(t.Rooms[r], step)
+				}
+			}
+			t.visit(a, path, departure, true)
+			result = addPath(result, path, departure, Move{Ant: ant, Name: name(ant)})
+		}
+		if len(failed) == len(flying) {
+			stranded = failed
+			break
+		}
+		flying = failed
+	}
+	releases := make(map[string][]int)
+	last := turn - 1
+	for group, ants := range waiting {
+		// ants of the group are stranded if any path from its start rooms is blocked
+		if path, _ := a.searchFrom(t, rt, group, a.routeGroup(rt, group).Starts, turn-1, horizon(turn-1)); path == nil {
+			stranded = append(stranded, ants...)
+			continue
+		}
+		// ants which haven't come into start room yet leave it from their turns
+		for _, ant := range ants {
+			release := arrivalTurn(m.Arrivals, ant) - 1
+			if release < turn-1 {
+				release = turn - 1
+			}
+			releases[group] = append(releases[group], release)
+			if release > last {
+				last = release
+			}
+		}
+	}
+	for _, d := range departures(a.scheduleLeft(o, t, rt, releases, horizon(last))) {
+		group := a.groupOf(d.Path.Start.Name)
+		ant := waiting[group][0]
+		waiting[group] = waiting[group][1:]
+		starts[ant] = d.Path.Start.Name
+		result = addPath(result, d.Path, d.Step, Move{Ant: ant, Name: name(ant)})
+	}
+	for _, step := range result {
+		sort.Slice(step, func(i, j int) bool { return step[i].Ant < step[j].Ant })
+	}
+	sort.Ints(stranded)
+	return result, stranded
+}
+
+// scheduleLeft - sends ants which are left in start rooms (the first steps when they can leave them by groups) by timetable,
+// paths are taken from min cost flow for every count of paths. Returns paths with the least count of turns
+func (a *anthive) scheduleLeft(o *Options, t *timetable, rt *router, releases map[string][]int, limit int) []*list {
+	if len(releases) == 0 {
+		return nil
+	}
+	var result []*list
+	steps := 0
+	// ants search paths in time only if some places are closed, like in the first plan
+	var search *router
+	if a.Timed {
+		search = rt
+	}
+	try := func(groups map[string][]*list) {
+		for group := range releases {
+			// paths through rooms where ants stay till the end can't take ants
+			var paths []*list
+			for _, path := range groups[group] {
+				if t.passable(path) {
+					paths = append(paths, path)
+				}
+			}
+			if len(paths) == 0 {
+				if path, _ := a.searchFrom(t, rt, group, a.routeGroup(rt, group).Starts, releases[group][0], limit); path != nil {
+					paths = append(paths, path)
+				}
+			}
+			groups[group] = paths
+		}
+		if curPaths, curSteps := a.schedule(t.copy(), search, groups, releases); curSteps > 0 && (steps == 0 || curSteps < steps) {
+			result, steps = curPaths, curSteps
+		}
+	}
+	if len(a.Colonies) > 0 {
+		groups := make(map[string][]*list)
+		for _, c := range a.Colonies {
+			if len(releases[c.Start]) > 0 {
+				groups[c.Start], _ = a.bestColonyPaths(o, c)
+			}
+		}
+		try(groups)
+		return result
+	}
+	n, source, sink := a.buildNetwork(o, nil, nil)
+	ants := 0
+	for _, group := range releases {
+		ants += len(group)
+	}
+	for flow := 0; ; flow++ {
+		try(a.groupPaths(n.paths(a)))
+		if flow >= ants || !n.augment(source, sink) {
+			break
+		}
+	}
+	return result
+}
+
+// searchFrom - returns the earliest path of the group from starts (indexes of rooms) and step of departure.
+// Limit of turns grows while path isn't found, returns nil if there is no path before the horizon
+func (a *anthive) searchFrom(t *timetable, rt *router, group string, starts []int, ready, horizon int) (*list, int) {
+	g := a.routeGroup(rt, group)
+	nearest := -1
+	for _, start := range starts {
+		if g.Turns[start] >= 0 && (nearest == -1 || g.Turns[start] < nearest) {
+			nearest = g.Turns[start]
+		}
+	}
+	if nearest == -1 {
+		return nil, 0
+	}
+	for extra := 4; ready+nearest+extra < horizon; extra *= 2 {
+		if path, departure := a.searchPath(t, rt, group, starts, ready, ready+nearest+extra); path != nil {
+			return path, departure
+		}
+	}
+	return a.searchPath(t, rt, group, starts, ready, horizon)
+}
+
+// departure - ant goes by the path on the step
+type departure struct {
+	Path *list
+	Step int
+}
+
+// departures - returns departures of all paths sorted by steps
+func departures(paths []*list) []departure {
+	var result []departure
+	for _, path := range paths {
+		for _, step := range path.Departures {
+			result = append(result, departure{Path: path, Step: step})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Step < result[j].Step })
+	return result
+}
+
+// passable - returns true if rooms of the path have places for ants which don't stay in them till the end
+func (t *timetable) passable(path *list) bool {
+	for node := path.Front; node != nil && node.Next != nil; node = node.Next {
+		if t.Held[node.Room] >= node.Room.Capacity {
+			return false
+		}
+	}
+	return true
+}
+
+// addMove - adds the move on the step
+func addMove(moves [][]Move, step int, move Move) [][]Move {
+	for len(moves) <= step {
+		moves = append(moves, []Move{})
+	}
+	moves[step] = append(moves[step], move)
+	return moves
+}
+
+// addPath - adds moves of ant which goes by the path on the step of departure
+func addPath(moves [][]Move, path *list, departure int, ant Move) [][]Move {
+	for node := path.Front; node != nil; node = node.Next {
+		ant.Room = node.Room.Name
+		moves = addMove(moves, departure+node.Dist-1, ant)
+	}
+	return moves
+}
@@ -0,0 +1,51 @@
+package anthive
+
+import "testing"
+
+func TestSimulate(t *testing.T) {
+	content := "4\n##start\ns 0 0\na 1 0\nb 1 2\nc 2 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e\n"
+	tests := []struct {
+		name    string
+		events  string
+		turns   int
+		reached int
+		changed bool // last plan is changed by its events
+		room    string
+		from    int // turn from which ants can't be in the room
+	}{
+		{"collapsed tunnel strands ant", "2 collapse a-e", 5, 3, true, "", 0},
+		{"filled room traps ant", "3 fill c", 4, 3, true, "c", 3},
+		{"ants go around filled room", "1 fill a", 6, 4, true, "a", 1},
+		{"event after the plan", "9 fill a", 4, 4, false, "", 0},
+	}
+	for _, test := range tests {
+		a, _ := solveTest(t, test.name, content, nil, 4)
+		events, err := ParseEvents(test.events)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		s, err := a.Simulate(events, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(s.Moves) != test.turns || s.Reached() != test.reached || s.Plans[len(s.Plans)-1].Changed != test.changed {
+			t.Errorf("%s: %d turns, %d ants reached end, changed %v, want %d turns, %d ants, changed %v", test.name,
+				len(s.Moves), s.Reached(), s.Plans[len(s.Plans)-1].Changed, test.turns, test.reached, test.changed)
+		}
+		if test.room == "" {
+			continue
+		}
+		// trapped ant stays in filled room, but other ants don't go into it
+		trapped := make(map[int]bool)
+		for _, ant := range s.Plans[len(s.Plans)-1].Trapped {
+			trapped[ant] = true
+		}
+		for turn, rooms := range antRooms(s.Moves) {
+			for ant, room := range rooms {
+				if turn+1 >= test.from && room == test.room && !trapped[ant] {
+					t.Errorf("%s: ant %d is in filled room '%s' on turn %d", test.name, ant, room, turn+1)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package anthive
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Tie-breaking of the solvers depends on order of next rooms (classic search of paths) and order of tunnels (networks and timetable).
+// Seed shuffles both orders, so the solver can choose other paths of the same cost. The same seed gives the same paths. Seed 0 doesn't shuffle: next rooms go in order of the map, tunnels go in reading order.
+// Best-of-N solving runs the solver with N seeds and keeps the result with the least count of turns
+
+// Run - count of turns of the solver with the seed
+type Run struct {
+	Seed  int64 `json:"seed"`
+	Turns int   `json:"turns"`
+}
+
+// shuffle - shuffles order of next rooms and order of tunnels of the run by the seed of options, seed 0 doesn't shuffle them
+func (a *anthive) shuffle(o *Options) {
+	o.order, o.tunnels = nil, nil
+	if o.Seed == 0 {
+		return
+	}
+	random := rand.New(rand.NewSource(o.Seed))
+	o.order = make(map[*room][]*room, len(a.RoomsOrder))
+	for _, r := range a.RoomsOrder {
+		next := make([]*room, 0, len(r.Paths))
+		for other := range r.Paths {
+			next = append(next, other)
+		}
+		// map hasn't order, so rooms are sorted before shuffling
+		sort.Slice(next, func(i, j int) bool { return next[i].Name < next[j].Name })
+		random.Shuffle(len(next), func(i, j int) { next[i], next[j] = next[j], next[i] })
+		o.order[r] = next
+	}
+	o.tunnels = append([][2]*room{}, a.Links...)
+	random.Shuffle(len(o.tunnels), func(i, j int) { o.tunnels[i], o.tunnels[j] = o.tunnels[j], o.tunnels[i] })
+}
+
+// next - returns next rooms of the room in order of the solver: shuffled by seed of options or in order of the map
+func (o *Options) next(r *room) []*room {
+	if o != nil && o.order != nil {
+		return o.order[r]
+	}
+	next := make([]*room, 0, len(r.Paths))
+	for other := range r.Paths {
+		next = append(next, other)
+	}
+	return next
+}
+
+// links - returns tunnels of the anthive in order of the solver: shuffled by seed of options or in reading order
+func (o *Options) links(a *anthive) [][2]*room {
+	if o != nil && o.tunnels != nil {
+		return o.tunnels
+	}
+	return a.Links
+}
+
+// BestRun - returns index of the run with the least count of turns, the first of them if they have the same count
+func BestRun(runs []Run) int {
+	best := -1
+	for i, run := range runs {
+		if best == -1 || run.Turns < runs[best].Turns {
+			best = i
+		}
+	}
+	return best
+}
+
+// WriteRuns - writes distribution of count of turns of runs with different seeds and the best run
+func WriteRuns(w io.Writer, runs []Run, best Run) error {
+	counts := make(map[int]int)
+	var turns []int
+	for _, run := range runs {
+		if counts[run.Turns] == 0 {
+			turns = append(turns, run.Turns)
+		}
+		counts[run.Turns]++
+	}
+	sort.Ints(turns)
+	distribution := make([]string, len(turns))
+	for i, t := range turns {
+		distribution[i] = fmt.Sprintf("%d turns: %d", t, counts[t])
+	}
+	_, err := fmt.Fprintf(w, "runs: %d, %s, best: seed %d with %d turns\n", len(runs), strings.Join(distribution, ", "), best.Seed, best.Turns)
+	return err
+}
@@ -0,0 +1,68 @@
+package anthive
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// sameCostMap - paths s-a-x-e, s-a-y-e, s-b-x-e and s-b-y-e have the same cost, seed chooses two of them
+const sameCostMap = "4\n##start\ns 0 0\na 1 0\nb 1 2\nx 2 0\ny 2 2\n##end\ne 3 1\ns-a\ns-b\na-x\na-y\nb-x\nb-y\nx-e\ny-e\n"
+
+func TestMatchSeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+	}{
+		{"example", exampleMap, 4},
+		{"paths of the same cost", sameCostMap, 4},
+	}
+	for _, test := range tests {
+		found := make(map[string]bool)
+		// seed 0 doesn't shuffle next rooms, they go in order of the map
+		for seed := int64(1); seed <= 8; seed++ {
+			name := fmt.Sprintf("%s, seed %d", test.name, seed)
+			// the same seed gives the same paths
+			_, moves := solveTest(t, name, test.content, &Options{Seed: seed}, test.turns)
+			_, again := solveTest(t, name, test.content, &Options{Seed: seed}, test.turns)
+			if fmt.Sprint(moves) != fmt.Sprint(again) {
+				t.Errorf("%s: moves differ between runs:\n%v\n%v", name, moves, again)
+			}
+			found[fmt.Sprint(moves)] = true
+		}
+		if test.content == sameCostMap && len(found) < 2 {
+			t.Errorf("%s: all seeds give the same moves", test.name)
+		}
+	}
+}
+
+func TestBestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		runs []Run
+		best int
+	}{
+		{"one run", []Run{{0, 5}}, 0},
+		{"the least count of turns", []Run{{3, 6}, {4, 4}, {5, 5}}, 1},
+		{"the first run of the same count", []Run{{3, 6}, {4, 5}, {5, 5}}, 1},
+		{"all runs are the same", []Run{{0, 4}, {1, 4}}, 0},
+	}
+	for _, test := range tests {
+		if best := BestRun(test.runs); best != test.best {
+			t.Errorf("%s: best run %d, want %d", test.name, best, test.best)
+		}
+	}
+}
+
+func TestWriteRuns(t *testing.T) {
+	runs := []Run{{0, 5}, {1, 4}, {2, 5}, {3, 4}}
+	var buf bytes.Buffer
+	if err := WriteRuns(&buf, runs, runs[1]); err != nil {
+		t.Fatal(err)
+	}
+	want := "runs: 4, 4 turns: 2, 5 turns: 2, best: seed 1 with 4 turns\n"
+	if buf.String() != want {
+		t.Errorf("runs are written as %q, want %q", buf.String(), want)
+	}
+}
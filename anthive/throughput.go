@@ -0,0 +1,135 @@
+package anthive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Steady state of the infinite stream of ants: start rooms send ants every turn without end.
+// Count of ants which reach end rooms every turn is the max flow of the network (vertex-disjoint paths for classic rooms),
+// every path takes as many ants every turn as its rooms and tunnels allow, so the dispatch pattern repeats every turn.
+// Min cost flow is used, so paths of the max flow have the least latency in sum.
+// Closures and arrivals change only the first turns, count of ants of start rooms doesn't matter for the stream
+
+// Throughput - steady state of the infinite stream of ants from start rooms
+type Throughput struct {
+	Ants      int              `json:"ants"`      // ants which reach end rooms every turn, 0 if it's unlimited
+	Unlimited bool             `json:"unlimited"` // start and end rooms are linked by tunnel without limit, all ants go together
+	Paths     []ThroughputPath `json:"paths"`     // sorted by latency
+	Steady    int              `json:"steady"`    // the first turn when ants of all paths reach end rooms
+	Period    int              `json:"period"`    // count of turns of the dispatch pattern
+	Pattern   [][]int          `json:"pattern"`   // ants which go into every path on every turn of the period
+}
+
+// ThroughputPath - path of the stream with ants which go into it every turn
+type ThroughputPath struct {
+	Rooms   []string `json:"rooms"`   // from start room to end room
+	Ants    int      `json:"ants"`    // ants which go into the path every turn, 0 if it's unlimited
+	Latency int      `json:"latency"` // turns from start room to end room
+}
+
+// Throughput - returns steady state of the infinite stream of ants for options of the run (nil means default options),
+// error if end rooms can't be reached
+func (a *anthive) Throughput(o *Options) (*Throughput, error) {
+	if len(a.Colonies) > 0 {
+		return nil, errors.New("throughput can't be found for colonies")
+	} else if a.Foraging {
+		return nil, errors.New("throughput can't be found for foraging")
+	} else if o != nil && len(o.Waypoints) > 0 {
+		return nil, errors.New("throughput can't be found with waypoints")
+	}
+	result := &Throughput{Period: 1}
+	// tunnel without limit takes all ants at once
+	for _, start := range a.Starts {
+		for _, end := range a.Ends {
+			startRoom, endRoom := a.Rooms[start], a.Rooms[end]
+			if !startRoom.canGo(endRoom) || startRoom.Capacities[endRoom] != 0 {
+				continue
+			} else if !result.Unlimited || startRoom.length(endRoom) < result.Paths[0].Latency {
+				result.Unlimited = true
+				result.Paths = []ThroughputPath{{Rooms: []string{start, end}, Latency: startRoom.length(endRoom)}}
+				result.Steady = startRoom.length(endRoom)
+			}
+		}
+	}
+	if result.Unlimited {
+		return result, nil
+	}
+	n, source, sink := a.buildNetwork(o, nil, nil)
+	// stream hasn't count of ants
+	for _, i := range n.Adj[source] {
+		n.Arcs[i].Cap = math.MaxInt32
+	}
+	for _, i := range n.Adj[sink] {
+		n.Arcs[i^1].Cap = math.MaxInt32
+	}
+	for n.augment(source, sink) {
+	}
+	index := make(map[string]int) // paths by rooms
+	var pattern []int
+	for _, path := range n.paths(a) {
+		rooms := []string{path.Start.Name}
+		for node := path.Front; node != nil; node = node.Next {
+			rooms = append(rooms, node.Room.Name)
+		}
+		key := strings.Join(rooms, "-")
+		if i, ok := index[key]; ok {
+			result.Paths[i].Ants++
+			pattern[i]++
+			continue
+		}
+		index[key] = len(result.Paths)
+		result.Paths = append(result.Paths, ThroughputPath{Rooms: rooms, Ants: 1, Latency: path.Dist})
+		pattern = append(pattern, 1)
+		if path.Dist > result.Steady {
+			result.Steady = path.Dist
+		}
+	}
+	if len(result.Paths) == 0 {
+		return nil, errors.New("path not found")
+	}
+	for _, path := range result.Paths {
+		result.Ants += path.Ants
+	}
+	result.Pattern = [][]int{pattern}
+	return result, nil
+}
+
+// WriteText - writes throughput, paths with their latency, the first turn of steady state and the dispatch pattern
+func (t *Throughput) WriteText(w io.Writer) error {
+	if t.Unlimited {
+		fmt.Fprintf(w, "throughput: unlimited, tunnel '%s' hasn't limit of ants\n", strings.Join(t.Paths[0].Rooms, "-"))
+		fmt.Fprintf(w, "latency: %d\n", t.Paths[0].Latency)
+		return nil
+	}
+	fmt.Fprintf(w, "throughput: %d ants per turn\n", t.Ants)
+	for i, path := range t.Paths {
+		fmt.Fprintf(w, "path %d: %s, latency: %d, ants per turn: %d\n", i+1, strings.Join(path.Rooms, "-"), path.Latency, path.Ants)
+	}
+	fmt.Fprintf(w, "steady state from turn: %d\n", t.Steady)
+	fmt.Fprintf(w, "period: %d\n", t.Period)
+	for turn, ants := range t.Pattern {
+		var dispatch []string
+		for i, count := range ants {
+			if count > 0 {
+				dispatch = append(dispatch, fmt.Sprintf("%d into path %d", count, i+1))
+			}
+		}
+		_, err := fmt.Fprintf(w, "turn %d: %s\n", turn+1, strings.Join(dispatch, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON - writes throughput as JSON document
+func (t *Throughput) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(t)
+}
@@ -0,0 +1,40 @@
+package anthive
+
+import "testing"
+
+func TestThroughput(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		ants      int // ants which reach end rooms every turn
+		unlimited bool
+		paths     int
+		steady    int
+	}{
+		{"two paths", "3\n##start\n1 23 3\n2 16 7\n3 16 3\n4 16 5\n5 9 3\n6 1 5\n7 4 8\n##end\ncool 9 5\ncool-4\n1-3\n4-3\n5-2\n3-5\n4-2\n2-1\n6-cool\n7-6\n7-2\n7-4\n6-5\n", 2, false, 2, 4},
+		{"room of one ant", "4\n##start\ns 0 0\na 1 0\nb 1 2\n##capacity 1\nw 2 1\nc 3 0\nd 3 2\n##end\ne 4 1\ns-a\ns-b\na-w\nb-w\nw-c\nw-d\nc-e\nd-e\n", 1, false, 1, 4},
+		{"wide path and short path", "4\n##start\ns 0 0\n##capacity 2\na 1 0\nb 1 2\n##end\ne 2 0\ns-a cap=2\na-e 2 cap=2\ns-b\nb-e\n", 3, false, 2, 3},
+		{"tunnel between start and end without limit", "3\n##start\ns 0 0\n##end\ne 2 0\ns-e\n", 0, true, 1, 1},
+	}
+	for _, test := range tests {
+		a := readTest(t, test.name, test.content)
+		th, err := a.Throughput(nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if th.Ants != test.ants || th.Unlimited != test.unlimited || len(th.Paths) != test.paths || th.Steady != test.steady {
+			t.Errorf("%s: %d ants per turn (unlimited %v) by %d paths from turn %d, want %d ants (unlimited %v) by %d paths from turn %d",
+				test.name, th.Ants, th.Unlimited, len(th.Paths), th.Steady, test.ants, test.unlimited, test.paths, test.steady)
+		}
+		// ants of the dispatch pattern are ants of paths
+		for i, path := range th.Paths {
+			sent := 0
+			for _, turn := range th.Pattern {
+				sent += turn[i]
+			}
+			if sent != path.Ants*th.Period {
+				t.Errorf("%s: %d ants go into path %d in period %d, want %d per turn", test.name, sent, i+1, th.Period, path.Ants)
+			}
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package anthive
+
+import (
+	"errors"
+	"sort"
+)
+
+// Solver with timetable (heuristic): ants are sent one by one, every ant takes the path where it arrives first.
+// Ant waits in start room while some room or tunnel of the path is busy or closed at the turn when ant would be there.
+// Paths are taken from min cost flow for every count of paths, the timetable with the least count of turns is chosen.
+// If some rooms or tunnels are closed on some turns, then ant also searches the path through free and open places
+// which brings it earlier, and the found path can be taken by next ants.
+// If ants come into start room on different turns (##arrivals), then every ant is sent from its turn
+
+// timetable - count of ants in rooms and count of ants which go into tunnels (by tunnelKey), index is step
+type timetable struct {
+	Rooms   map[*room][]int
+	Tunnels map[[2]*room][]int
+	Held    map[*room]int // count of ants which stay in rooms on all steps (they aren't planned yet)
+}
+
+func newTimetable() *timetable {
+	return &timetable{Rooms: make(map[*room][]int), Tunnels: make(map[[2]*room][]int), Held: make(map[*room]int)}
+}
+
+// copy - returns timetable with the same taken places
+func (t *timetable) copy() *timetable {
+	result := newTimetable()
+	for r, steps := range t.Rooms {
+		result.Rooms[r] = append([]int{}, steps...)
+	}
+	for key, steps := range t.Tunnels {
+		result.Tunnels[key] = append([]int{}, steps...)
+	}
+	for r, ants := range t.Held {
+		result.Held[r] = ants
+	}
+	return result
+}
+
+// last - returns the last step when some place is taken, -1 if there is no such step
+func (t *timetable) last() int {
+	result := -1
+	for _, steps := range t.Rooms {
+		if len(steps)-1 > result {
+			result = len(steps) - 1
+		}
+	}
+	for _, steps := range t.Tunnels {
+		if len(steps)-1 > result {
+			result = len(steps) - 1
+		}
+	}
+	return result
+}
+
+// free - returns true if room has place for one more ant on the step (turn of step is step+1) and it's open
+func (t *timetable) free(r *room, step int) bool {
+	return count(t.Rooms[r], step)+t.Held[r] < r.Capacity && closedOn(r.Closures, step+1, step+1) == 0
+}
+
+// count - returns count of ants on the step
+func count(steps []int, step int) int {
+	if step < len(steps) {
+		return steps[step]
+	}
+	return 0
+}
+
+// take - adds ant on the step
+func take(steps []int, step int) []int {
+	for len(steps) <= step {
+		steps = append(steps, 0)
+	}
+	steps[step]++
+	return steps
+}
+
+// visit - returns true if ant which goes by the path on the step of departure finds free and open places in all rooms and tunnels.
+// If reserve is true, then places are taken
+func (t *timetable) visit(a *anthive, path *list, departure int, reserve bool) bool {
+	prev, prevDist := path.Start, 0
+	for node := path.Front; node != nil; node = node.Next {
+		// ant goes into tunnel on the next step after arrival into previous room, and it's in the room only on step of arrival.
+		// Turn of step is step+1
+		key, entry, arrival := tunnelKey(prev, node.Room), departure+prevDist, departure+node.Dist-1
+		if reserve {
+			t.Tunnels[key] = take(t.Tunnels[key], entry)
+		} else if count(t.Tunnels[key], entry) >= a.linkCapacity(prev, node.Room) || closedOn(prev.TunnelClosures[node.Room], entry+1, arrival+1) != 0 {
+			return false
+		}
+		// end room is inside round trip
+		if node.Next != nil && !a.isEnd(node.Room.Name) {
+			if reserve {
+				t.Rooms[node.Room] = take(t.Rooms[node.Room], arrival)
+			} else if !t.free(node.Room, arrival) {
+				return false
+			}
+		}
+		prev, prevDist = node.Room, node.Dist
+	}
+	return true
+}
+
+// scheduleAnts - sends ants of every group (start room with count of ants, or "" for other ants) by paths of the group,
+// every ant takes the path where it arrives first, groups send ants in turn.
+// Returns paths which take ants (with steps of departures) and count of turns, 0 turns if some group hasn't paths
+func (a *anthive) scheduleAnts(o *Options, groups map[string][]*list) ([]*list, int) {
+	releases := make(map[string][]int)
+	for _, group := range append([]string{""}, a.Starts...) {
+		ants, ok := a.StartAnts[group]
+		if group == "" {
+			ants = a.freeAnts()
+		} else if !ok {
+			continue
+		}
+		for ant := 1; ant <= ants; ant++ {
+			// ants without count of start room are numbered first, only they can have arrivals
+			releases[group] = append(releases[group], arrivalTurn(a.Arrivals, ant)-1)
+		}
+	}
+	// round trips, paths through waypoints and exactly given paths aren't searched in time
+	var rt *router
+	if a.Timed && !a.Foraging && len(o.Waypoints) == 0 && (o.pinned == nil || o.Fill) {
+		rt = a.newRouter(o)
+	}
+	return a.schedule(newTimetable(), rt, groups, releases)
+}
+
+// schedule - sends ants of groups (the first steps when ants can leave start rooms by groups, in growing order),
+// places which are taken in timetable are busy. If router isn't nil, then ants also search paths in time.
+// Returns paths which take ants and count of turns, 0 turns if some group hasn't paths
+func (a *anthive) schedule(t *timetable, rt *router, groups map[string][]*list, releases map[string][]int) ([]*list, int) {
+	var order []string
+	sent, ants := 0, 0
+	for _, group := range append([]string{""}, a.Starts...) {
+		if len(releases[group]) == 0 {
+			continue
+		} else if len(groups[group]) == 0 {
+			return nil, 0
+		}
+		order = append(order, group)
+		ants += len(releases[group])
+	}
+	next := make(map[*list]int) // the first step when the path can take ant
+	// searched path can't bring ant of the group earlier than on this turn, because places are only taken
+	searched := make(map[string]int)
+	sentOf := make(map[string]int)
+	for sent < ants {
+		for _, group := range order {
+			if sentOf[group] == len(releases[group]) {
+				continue
+			}
+			release := releases[group][sentOf[group]]
+			var best *list
+			for _, path := range groups[group] {
+				if next[path] < release {
+					next[path] = release
+				}
+				for !t.visit(a, path, next[path], false) {
+					next[path]++
+				}
+				if best == nil || next[path]+path.Dist < next[best]+best.Dist {
+					best = path
+				}
+			}
+			if arrival := next[best] + best.Dist; rt != nil && arrival > searched[group] {
+				path, departure := a.searchPath(t, rt, group, a.routeGroup(rt, group).Starts, release, arrival)
+				if searched[group] = arrival; path != nil {
+					groups[group] = append(groups[group], path)
+					next[path], best = departure, path
+					searched[group] = departure + path.Dist
+				}
+			}
+			t.visit(a, best, next[best], true)
+			best.Departures = append(best.Departures, next[best])
+			sentOf[group]++
+			sent++
+		}
+	}
+	var result []*list
+	steps := 0
+	for _, group := range order {
+		for _, path := range groups[group] {
+			if last := len(path.Departures) - 1; last >= 0 {
+				result = append(result, path)
+				if path.Departures[last]+path.Dist > steps {
+					steps = path.Departures[last] + path.Dist
+				}
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Dist < result[j].Dist })
+	return result, steps
+}
+
+// router - rooms by their indexes in RoomsOrder for search of paths in time
+type router struct {
+	Index    map[*room]int          // indexes of rooms in RoomsOrder
+	Adjacent [][]int                // rooms where ant can go from the room, start rooms are excluded
+	Groups   map[string]*routeGroup // by groups, they are found when they are needed
+	Closed   int                    // the last turn when some room or tunnel is closed, 0 if there is no closures
+}
+
+// routeGroup - start rooms of the group and count of turns from rooms to ends of the group without waiting,
+// -1 if ant can't reach ends from the room
+type routeGroup struct {
+	Starts []int
+	Turns  []int
+}
+
+func (a *anthive) newRouter(o *Options) *router {
+	index := make(map[*room]int, len(a.RoomsOrder))
+	for i, r := range a.RoomsOrder {
+		index[r] = i
+	}
+	rt := &router{Index: index, Adjacent: make([][]int, len(a.RoomsOrder)), Groups: make(map[string]*routeGroup)}
+	for _, l := range o.links(a) {
+		for _, pair := range [][2]*room{l, {l[1], l[0]}} {
+			if pair[0].canGo(pair[1]) && !a.isStart(pair[1].Name) {
+				rt.Adjacent[index[pair[0]]] = append(rt.Adjacent[index[pair[0]]], index[pair[1]])
+			}
+			for _, turns := range pair[0].TunnelClosures[pair[1]] {
+				if turns.To > rt.Closed {
+					rt.Closed = turns.To
+				}
+			}
+		}
+	}
+	for _, r := range a.RoomsOrder {
+		for _, turns := range r.Closures {
+			if turns.To > rt.Closed {
+				rt.Closed = turns.To
+			}
+		}
+	}
+	return rt
+}
+
+// routeGroup - returns start rooms of the group and turns to its ends. Ant can't go through start rooms and other end rooms
+func (a *anthive) routeGroup(rt *router, group string) *routeGroup {
+	if g, ok := rt.Groups[group]; ok {
+		return g
+	}
+	starts, ends := a.groupRooms(group)
+	g := &routeGroup{Turns: make([]int, len(a.RoomsOrder))}
+	reversed := make([][]int, len(a.RoomsOrder))
+	var queue []int
+	for i, r := range a.RoomsOrder {
+		g.Turns[i] = -1
+		if ends[r] {
+			g.Turns[i] = 0
+			queue = append(queue, i)
+		} else if starts[r] {
+			g.Starts = append(g.Starts, i)
+		}
+		for _, next := range rt.Adjacent[i] {
+			reversed[next] = append(reversed[next], i)
+		}
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, prev := range reversed[cur] {
+			r := a.RoomsOrder[prev]
+			turns := g.Turns[cur] + r.length(a.RoomsOrder[cur])
+			if a.isEnd(r.Name) || g.Turns[prev] >= 0 && g.Turns[prev] <= turns {
+				continue
+			}
+			g.Turns[prev] = turns
+			if !starts[r] {
+				queue = append(queue, prev)
+			}
+		}
+	}
+	rt.Groups[group] = g
+	return g
+}
+
+// searchPath - returns the path of the group from one of starts (indexes of rooms) where ant arrives before the turn limit,
+// and step of departure which isn't earlier than ready step.
+// Ant can wait only in the room where it starts, ant which waits in the room between start and end takes its place.
+// It goes through rooms and tunnels which are free and open on its turns.
+// Rooms which are too far from ends of the group (by turns) aren't visited. Returns nil if there is no such path
+func (a *anthive) searchPath(t *timetable, rt *router, group string, starts []int, ready, limit int) (*list, int) {
+	g := a.routeGroup(rt, group)
+	// state of ant is index of room * width + turn
+	width := limit + 1
+	parent := make(map[int]int)
+	queue := make(map[int][]int) // indexes of rooms by turns
+	found := -1
+	// after this step places are free and open all the time, so later departures don't bring ant earlier
+	quiet := t.last() + 1
+	if rt.Closed > quiet {
+		quiet = rt.Closed
+	}
+	if ready > quiet {
+		quiet = ready
+	}
+	waiting := make([]bool, len(starts))
+	for i := range waiting {
+		waiting[i] = true
+	}
+	for turn := ready; turn+1 < limit; turn++ {
+		for i, start := range starts {
+			r := a.RoomsOrder[start]
+			if waiting[i] && turn > ready && !a.isStart(r.Name) {
+				waiting[i] = t.free(r, turn-1)
+			}
+			if waiting[i] && turn <= quiet+1 && g.Turns[start] >= 0 && turn+g.Turns[start] < limit {
+				// ant which waits in the room doesn't come back to it
+				parent[start*width+turn] = -1
+				queue[turn] = append(queue[turn], start)
+			}
+		}
+		if turn > quiet+1 && len(queue) == 0 {
+			break
+		}
+		for _, cur := range queue[turn] {
+			from := a.RoomsOrder[cur]
+			for _, next := range rt.Adjacent[cur] {
+				to := a.RoomsOrder[next]
+				arrival := turn + from.length(to)
+				state := next*width + arrival
+				if g.Turns[next] < 0 || arrival+g.Turns[next] >= limit {
+					continue
+				} else if _, ok := parent[state]; ok {
+					continue
+				} else if count(t.Tunnels[tunnelKey(from, to)], turn) >= a.linkCapacity(from, to) || closedOn(from.TunnelClosures[to], turn+1, arrival) != 0 {
+					continue
+				} else if g.Turns[next] == 0 {
+					parent[state], found, limit = cur*width+turn, state, arrival
+					continue
+				} else if !t.free(to, arrival-1) {
+					continue
+				}
+				parent[state] = cur*width + turn
+				queue[arrival] = append(queue[arrival], next)
+			}
+		}
+		delete(queue, turn)
+	}
+	if found == -1 {
+		return nil, 0
+	}
+	// state with parent -1 is start room on step of departure
+	var states []int
+	state := found
+	for prev := parent[state]; prev != -1; prev = parent[state] {
+		states = append(states, state)
+		state = prev
+	}
+	departure := state % width
+	path := &list{Start: a.RoomsOrder[state/width], Dist: found%width - departure}
+	for i := len(states) - 1; i >= 0; i-- {
+		path.PushBack(a.RoomsOrder[states[i]/width])
+		path.Back.Dist = states[i]%width - departure
+	}
+	return path, departure
+}
+
+// groupRooms - returns start rooms and end rooms of the group (start room with count of ants, or "" for other ants)
+func (a *anthive) groupRooms(group string) (map[*room]bool, map[*room]bool) {
+	starts := make(map[*room]bool)
+	for _, start := range a.Starts {
+		if a.groupOf(start) == group {
+			starts[a.Rooms[start]] = true
+		}
+	}
+	ends := make(map[*room]bool)
+	for _, c := range a.Colonies {
+		if c.Start == group {
+			ends[a.Rooms[c.End]] = true
+			return starts, ends
+		}
+	}
+	for _, end := range a.Ends {
+		ends[a.Rooms[end]] = true
+	}
+	return starts, ends
+}
+
+// groupPaths - returns paths of every group (start room with count of ants, or "" for other ants)
+// with all paths without rooms and limit of the group, they can be closed on different turns
+func (a *anthive) groupPaths(sortedPaths []*list) map[string][]*list {
+	groups := make(map[string][]*list)
+	for _, path := range sortedPaths {
+		groups[a.groupOf(path.Start.Name)] = append(groups[a.groupOf(path.Start.Name)], path)
+	}
+	for _, group := range append([]string{""}, a.Starts...) {
+		if _, ok := a.StartAnts[group]; !ok && group != "" {
+			continue
+		}
+		groups[group] = append(groups[group], a.directPaths(group, a.Ends)...)
+	}
+	return groups
+}
+
+// matchTimetable - finds paths by min cost flow for every count of paths and sends ants by them with timetable
+func (a *anthive) matchTimetable(o *Options) error {
+	n, source, sink := a.buildNetwork(o, nil, nil)
+	var paths []*list
+	steps := 0
+	for flow := 0; ; flow++ {
+		if curPaths, curSteps := a.scheduleAnts(o, a.groupPaths(n.paths(a))); curSteps > 0 && (steps == 0 || curSteps < steps) {
+			paths, steps = curPaths, curSteps
+		}
+		if flow >= a.AntsCount || !n.augment(source, sink) {
+			break
+		}
+	}
+	if steps == 0 {
+		return errors.New("path not found")
+	}
+	a.StepsCount = steps
+	a.Result.Paths = paths
+	a.Result.Map = a.Map()
+	return nil
+}
@@ -0,0 +1,66 @@
+package anthive
+
+import "testing"
+
+func TestMatchClosures(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+		room    string // closed room, or closed tunnel from room (length 1)
+		to      string // closed tunnel into room, empty for closed room
+		closed  []int
+	}{
+		{"ants wait for closed room", "3\n##start\ns 0 0\n##closed 1-2\na 1 0\n##end\ne 2 0\ns-a\na-e\n", 6, "a", "", []int{1, 2}},
+		{"ants go around closed room", "3\n##start\ns 0 0\n##closed 1-4\na 1 0\nb 1 2\nc 2 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e\n", 5, "a", "", []int{1, 2, 3, 4}},
+		{"ants wait for closed tunnel", "3\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\n##closed 2-3\na-e\n", 6, "a", "e", []int{2, 3}},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		rooms, passed := antRooms(moves), entries(a.Start, moves, test.room, test.to)
+		for _, turn := range test.closed {
+			if turn > len(moves) {
+				continue
+			}
+			for ant, room := range rooms[turn-1] {
+				if test.to == "" && room == test.room {
+					t.Errorf("%s: ant %d is in closed room '%s' on turn %d", test.name, ant, room, turn)
+				}
+			}
+			if test.to != "" && passed[turn-1] > 0 {
+				t.Errorf("%s: %d ants pass closed tunnel '%s-%s' on turn %d", test.name, passed[turn-1], test.room, test.to, turn)
+			}
+		}
+	}
+}
+
+func TestMatchArrivals(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+	}{
+		{"ants come on turns", "3\n##start\ns 0 0\na 1 0\n##end\ne 2 0\n##arrivals 1:1 5:2\ns-a\na-e\n", 7},
+		{"ant every turn", "4\n##start\ns 0 0\na 1 0\nb 1 2\n##end\ne 2 0\n##arrivals rate=1\ns-a\na-e\ns-b\nb-e\n", 5},
+		{"ants every few turns", "5\n##start\ns 0 0\na 1 0\nb 1 2\nc 2 2\n##end\ne 2 0\n##arrivals rate=2/3\ns-a\na-e\ns-b\nb-c\nc-e\n", 8},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		// ants come in order of their numbers and can't leave start room before their turn
+		var arrival []int
+		for _, arrivals := range a.Arrivals {
+			for i := 0; i < arrivals.Ants; i++ {
+				arrival = append(arrival, arrivals.Turn)
+			}
+		}
+		left := make(map[int]bool)
+		for turn, step := range moves {
+			for _, move := range step {
+				if !left[move.Ant] && turn+1 < arrival[move.Ant-1] {
+					t.Errorf("%s: ant %d leaves start room on turn %d, it comes on turn %d", test.name, move.Ant, turn+1, arrival[move.Ant-1])
+				}
+				left[move.Ant] = true
+			}
+		}
+	}
+}
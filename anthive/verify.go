@@ -0,0 +1,285 @@
+package anthive
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseMoves - reads moves of one step from result line. Format: L<ant>-<room> L<ant>-<room> ...
+// Ant of colony is L<colony>.<n>, its number is set by Verify. Move of the way back is L<ant><-<room>
+func ParseMoves(line string) ([]Move, error) {
+	fields := strings.Fields(line)
+	moves := make([]Move, len(fields))
+	for i, field := range fields {
+		splited := strings.SplitN(field, "-", 2)
+		if len(splited) != 2 || !strings.HasPrefix(splited[0], "L") || len(splited[1]) < 1 {
+			return nil, fmt.Errorf("invalid format of move: '%v'", field)
+		}
+		name := splited[0][1:]
+		back := strings.HasSuffix(name, "<")
+		name = strings.TrimSuffix(name, "<")
+		if ant, err := strconv.Atoi(name); err == nil && ant > 0 {
+			moves[i] = Move{Ant: ant, Room: splited[1], Back: back}
+			continue
+		}
+		dot := strings.LastIndex(name, ".")
+		if number, err := strconv.Atoi(name[dot+1:]); dot < 1 || err != nil || number < 1 {
+			return nil, fmt.Errorf("invalid number of ant: '%v'", field)
+		}
+		moves[i] = Move{Room: splited[1], Name: name, Back: back}
+	}
+	return moves, nil
+}
+
+// Rules for Moves:
+// Ants are numbered from 1 to AntsCount, all of them start from start rooms (start room of ant is found by its first move)
+// Ants of colony are L<colony>.<n> with n from 1 to count of ants of colony, they go from start room to end room of colony
+// Start room with count of ants sends exactly this count of ants
+// Ant can't leave start room before the turn when it comes there (##arrivals)
+// Ant moves only by relations (one-way relation only in its direction), once per step
+// Move to the room by tunnel with length k is written on the step of arrival, ant leaves previous room k-1 steps before.
+// Step without moves is an empty line
+// Room (except Start and End) can have only one ant, or count of ants by its capacity
+// Tunnel can be entered by one ant each step, or count of ants by its capacity (tunnel between Start and End hasn't limit by default)
+// Closed room can't have ants on its turns, closed tunnel can't have ants inside on its turns
+// All ants must reach one of end rooms (end room of colony)
+// In foraging (##foraging) ant goes from end room back to one of start rooms, moves of the way back are L<ant><-<room>
+// and ant can't go through end rooms on the way back
+// Ant can't visit avoided rooms of the run, and it must pass waypoints of the run in their order before end room
+
+// stay - ant is in the room from step Arrived to step Left-1
+type stay struct {
+	Arrived, Left int
+}
+
+// Verify - returns an error if moves break rules of the Map
+func (m *Map) Verify(moves [][]Move) error {
+	if err := m.numberAnts(moves); err != nil {
+		return err
+	}
+	length := make(map[string]map[string]int)
+	capacity := make(map[string]map[string]int)
+	closed := make(map[string]map[string][]Turns)
+	for _, r := range m.Rooms {
+		length[r.Name] = make(map[string]int)
+		capacity[r.Name] = make(map[string]int)
+		closed[r.Name] = make(map[string][]Turns)
+	}
+	for _, l := range m.Links {
+		k, c := l.Length, m.tunnelCapacity(l.From, l.To)
+		if k < 1 {
+			k = 1
+		}
+		length[l.From][l.To], capacity[l.From][l.To], closed[l.From][l.To] = k, c, l.Closed
+		if !l.OneWay {
+			length[l.To][l.From], capacity[l.To][l.From], closed[l.To][l.From] = k, c, l.Closed
+		}
+	}
+	// count of ants which went into the tunnel on the step
+	type entry struct {
+		From, To string
+		Step     int
+	}
+	entered := make(map[entry]int)
+	position := m.AntStarts(moves)
+	arrived := make([]int, m.AntsCount+1)
+	for i := range position {
+		// ant can wait in start room any time, so empty steps before the first move don't matter
+		arrived[i] = math.MinInt32
+	}
+	stays := make(map[string][]stay)
+	returning := make([]bool, m.AntsCount+1) // ant of foraging reached end room
+	passed := make([]int, m.AntsCount+1)     // count of waypoints which ant passed
+	avoided := make(map[string]bool)
+	for _, name := range m.Avoid {
+		avoided[name] = true
+	}
+	for i, step := range moves {
+		turn := i + 1
+		moved := make(map[int]bool)
+		for _, move := range step {
+			if move.Ant > m.AntsCount {
+				return fmt.Errorf("step %d: unknown ant L%d", turn, move.Ant)
+			} else if moved[move.Ant] {
+				return fmt.Errorf("step %d: ant %v moves twice", turn, m.AntName(move.Ant))
+			} else if _, ok := length[move.Room]; !ok {
+				return fmt.Errorf("step %d: unknown room '%v'", turn, move.Room)
+			} else if avoided[move.Room] {
+				return fmt.Errorf("step %d: ant %v visits avoided room '%v'", turn, m.AntName(move.Ant), move.Room)
+			}
+			from := position[move.Ant]
+			k := length[from][move.Room]
+			if m.IsEnd(from) && !m.Foraging {
+				return fmt.Errorf("step %d: ant %v already reached end", turn, m.AntName(move.Ant))
+			} else if returning[move.Ant] && m.IsStart(from) {
+				return fmt.Errorf("step %d: ant %v already returned to start", turn, m.AntName(move.Ant))
+			} else if returning[move.Ant] && m.IsEnd(move.Room) {
+				return fmt.Errorf("step %d: ant %v goes through end room '%v' on the way back", turn, m.AntName(move.Ant), move.Room)
+			} else if move.Back && !returning[move.Ant] {
+				return fmt.Errorf("step %d: ant %v isn't on the way back", turn, m.AntName(move.Ant))
+			} else if !move.Back && returning[move.Ant] {
+				back := move
+				back.Back = true
+				return fmt.Errorf("step %d: ant %v goes back, its move must be written as %v", turn, m.AntName(move.Ant), back)
+			} else if k == 0 && length[move.Room][from] != 0 {
+				return fmt.Errorf("step %d: tunnel '%v>%v' is one-way", turn, move.Room, from)
+			} else if k == 0 {
+				return fmt.Errorf("step %d: rooms '%v' and '%v' aren't linked", turn, from, move.Room)
+			} else if turn-k < arrived[move.Ant] {
+				return fmt.Errorf("step %d: ant %v can't pass tunnel '%v-%v' with length %d so fast", turn, m.AntName(move.Ant), from, move.Room, k)
+			}
+			moved[move.Ant] = true
+			// ant leaves the room, then goes through the tunnel
+			left := turn - k + 1
+			if come := arrivalTurn(m.Arrivals, move.Ant); m.IsStart(from) && left < come {
+				return fmt.Errorf("step %d: ant %v leaves start room before it comes there on turn %d", left, m.AntName(move.Ant), come)
+			}
+			if !m.IsStart(from) && !m.IsEnd(from) {
+				stays[from] = append(stays[from], stay{Arrived: arrived[move.Ant], Left: left})
+			}
+			tunnel := entry{From: from, To: move.Room, Step: left}
+			if tunnel.From > tunnel.To {
+				tunnel.From, tunnel.To = tunnel.To, tunnel.From
+			}
+			entered[tunnel]++
+			if c := capacity[from][move.Room]; entered[tunnel] > c && c == 1 {
+				return fmt.Errorf("step %d: more than one ant goes into tunnel '%v-%v'", left, from, move.Room)
+			} else if entered[tunnel] > c {
+				return fmt.Errorf("step %d: more than %d ants go into tunnel '%v-%v'", left, c, from, move.Room)
+			} else if closedTurn := closedOn(closed[from][move.Room], left, turn); closedTurn != 0 {
+				return fmt.Errorf("step %d: tunnel '%v-%v' is closed", closedTurn, from, move.Room)
+			}
+			position[move.Ant] = move.Room
+			arrived[move.Ant] = turn
+			returning[move.Ant] = returning[move.Ant] || m.Foraging && m.IsEnd(move.Room)
+			if passed[move.Ant] < len(m.Waypoints) && move.Room == m.Waypoints[passed[move.Ant]] {
+				passed[move.Ant]++
+			} else if passed[move.Ant] < len(m.Waypoints) && m.IsEnd(move.Room) {
+				return fmt.Errorf("step %d: ant %v reached end without waypoint '%v'", turn, m.AntName(move.Ant), m.Waypoints[passed[move.Ant]])
+			}
+		}
+	}
+	for ant := 1; ant <= m.AntsCount; ant++ {
+		if !m.IsStart(position[ant]) && !m.IsEnd(position[ant]) {
+			stays[position[ant]] = append(stays[position[ant]], stay{Arrived: arrived[ant], Left: math.MaxInt32})
+		}
+	}
+	if err := m.verifyCapacity(stays); err != nil {
+		return err
+	} else if err := m.verifyClosed(stays); err != nil {
+		return err
+	}
+	for ant := 1; ant <= m.AntsCount; ant++ {
+		if c := m.antColony(ant); c != nil && position[ant] != c.End {
+			return fmt.Errorf("ant %v didn't reach end room '%v' of its colony", m.AntName(ant), c.End)
+		} else if m.Foraging && (!returning[ant] || !m.IsStart(position[ant])) {
+			return fmt.Errorf("ant %v didn't return to start", m.AntName(ant))
+		} else if !m.Foraging && !m.IsEnd(position[ant]) {
+			return fmt.Errorf("ant %v didn't reach end", m.AntName(ant))
+		}
+	}
+	return m.verifyStartAnts(moves)
+}
+
+// numberAnts - sets numbers of ants of colonies by their names. Map with colonies must have only named ants
+func (m *Map) numberAnts(moves [][]Move) error {
+	first := make(map[string]int)
+	ants := make(map[string]int)
+	ant := 1
+	for _, c := range m.Colonies {
+		first[c.Name], ants[c.Name] = ant, c.Ants
+		ant += c.Ants
+	}
+	for i, step := range moves {
+		for j, move := range step {
+			if move.Name == "" && len(m.Colonies) > 0 {
+				return fmt.Errorf("step %d: ant L%d must be written as L<colony>.<n>", i+1, move.Ant)
+			} else if move.Name == "" {
+				continue
+			}
+			dot := strings.LastIndex(move.Name, ".")
+			number, _ := strconv.Atoi(move.Name[dot+1:])
+			if number > ants[move.Name[:dot]] {
+				return fmt.Errorf("step %d: unknown ant L%v", i+1, move.Name)
+			}
+			moves[i][j].Ant = first[move.Name[:dot]] + number - 1
+		}
+	}
+	return nil
+}
+
+// verifyStartAnts - returns an error if start room with count of ants sends another count of ants
+func (m *Map) verifyStartAnts(moves [][]Move) error {
+	if len(m.StartAnts) == 0 {
+		return nil
+	}
+	sent := make(map[string]int)
+	for _, start := range m.AntStarts(moves)[1:] {
+		sent[start]++
+	}
+	for _, start := range m.Starts {
+		if ants, ok := m.StartAnts[start]; ok && sent[start] != ants {
+			return fmt.Errorf("start room '%v' must send %d ants, but sends %d", start, ants, sent[start])
+		}
+	}
+	return nil
+}
+
+// verifyClosed - returns an error for the first step when some closed room has ant
+func (m *Map) verifyClosed(stays map[string][]stay) error {
+	errStep, errRoom := 0, ""
+	for _, r := range m.Rooms {
+		for _, s := range stays[r.Name] {
+			if step := closedOn(r.Closed, s.Arrived, s.Left-1); step != 0 && (errRoom == "" || step < errStep) {
+				errStep, errRoom = step, r.Name
+			}
+		}
+	}
+	if errRoom == "" {
+		return nil
+	}
+	return fmt.Errorf("step %d: room '%v' is closed", errStep, errRoom)
+}
+
+// verifyCapacity - returns an error for the first step when some room has more ants than its capacity.
+// Ant can enter room which was left on the same step
+func (m *Map) verifyCapacity(stays map[string][]stay) error {
+	errStep, errRoom, errCapacity := 0, "", 0
+	for _, r := range m.Rooms {
+		if m.IsEnd(r.Name) || len(stays[r.Name]) == 0 {
+			continue
+		}
+		capacity := r.Capacity
+		if capacity < 1 {
+			capacity = 1
+		}
+		// +1 on arrival, -1 on leaving; leaving goes first on the same step
+		type event struct{ Step, Delta int }
+		events := make([]event, 0, 2*len(stays[r.Name]))
+		for _, s := range stays[r.Name] {
+			events = append(events, event{s.Arrived, 1}, event{s.Left, -1})
+		}
+		sort.Slice(events, func(i, j int) bool {
+			return events[i].Step < events[j].Step || events[i].Step == events[j].Step && events[i].Delta < events[j].Delta
+		})
+		count := 0
+		for _, e := range events {
+			count += e.Delta
+			if count > capacity {
+				if errRoom == "" || e.Step < errStep {
+					errStep, errRoom, errCapacity = e.Step, r.Name, capacity
+				}
+				break
+			}
+		}
+	}
+	if errRoom == "" {
+		return nil
+	} else if errCapacity == 1 {
+		return fmt.Errorf("step %d: room '%v' has more than one ant", errStep, errRoom)
+	}
+	return fmt.Errorf("step %d: room '%v' has more than %d ants", errStep, errRoom, errCapacity)
+}
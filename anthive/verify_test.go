@@ -0,0 +1,29 @@
+package anthive
+
+import "testing"
+
+func TestVerifySolverMultiStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		turns   int
+	}{
+		{"counted starts", "6\n##start 2\ns1 0 0\n##start 4\ns2 0 4\na 1 0\nb 1 2\nc 1 4\n##end\ne 2 2\ns1-a\ns1-b\ns2-b\ns2-c\na-e\nb-e\nc-e\n", 5},
+		{"starts without count and several ends", "5\n##start\ns1 0 0\n##start\ns2 0 4\na 1 0\nb 1 4\n##end\ne1 2 0\n##end\ne2 2 4\ns1-a\ns2-a\ns2-b\na-e1\nb-e2\nb-e1\n", 4},
+		{"counted and uncounted starts", "7\n##start 3\ns1 0 0\n##start\ns2 0 4\n##start\ns3 0 8\na 1 0\nb 1 4\nc 1 8\n##end\ne 2 4\ns1-a\ns1-b\ns2-b\ns3-b\ns3-c\na-e\nb-e\nc-e\n", 5},
+		{"start without count is the only way", "3\n##start 1\nr0 0 0\n##start\nr1 1 1\n##end\nr5 2 2\n##end\nr6 3 3\nr6-r1\nr0-r5\nr0-r6\n", 1},
+	}
+	for _, test := range tests {
+		a, moves := solveTest(t, test.name, test.content, nil, test.turns)
+		// start room with count sends exactly its ants
+		counts := make(map[string]int)
+		for _, start := range a.Result.Map.AntStarts(moves)[1:] {
+			counts[start]++
+		}
+		for start, ants := range a.StartAnts {
+			if counts[start] != ants {
+				t.Errorf("%s: %d ants go from '%s', want %d", test.name, counts[start], start, ants)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package anthive
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Solver for the runs with waypoints (heuristic): path of ant is chained from the cheapest parts between start room,
+// waypoints in their order and end room. Rooms of previous parts are expensive, so parts don't meet if it's possible,
+// but ant can go into the room of waypoint and back by the same tunnel (feeding chamber in dead end).
+// Every next path is searched with extra cost of rooms of previous paths, ants are sent by timetable
+// for every count of paths and the timetable with the least count of turns is chosen
+
+// Options - changes of the anthive for one run, they aren't written in the map
+type Options struct {
+	Avoid     []string   // rooms which ants can't visit, their tunnels are removed before search of paths
+	Waypoints []string   // rooms which every ant passes in this order on its way to end room
+	Objective string     // what the solver minimizes: one of Objectives, empty is OBJECTIVE_TURNS
+	Paths     [][]string // paths which ants take: rooms from start room to end room (ParsePaths)
+	Fill      bool       // the solver adds other paths to Paths, else ants take exactly Paths
+	Improve   bool       // local search changes paths of the solver while it reduces count of turns
+	Seed      int64      // seed of tie-breaking of the solver, the same seed gives the same paths, 0 doesn't shuffle
+	Edges     bool       // paths are edge-disjoint: rooms have no limit of ants, tunnels take one ant per turn
+
+	pinned  []*list           // Paths checked by the anthive (SetOptions)
+	order   map[*room][]*room // order of next rooms shuffled by Seed, nil keeps order of the map
+	tunnels [][2]*room        // order of tunnels shuffled by Seed, nil keeps order of reading
+}
+
+// Rules for Options:
+// Avoided rooms and waypoints must be rooms of the map, but not start and end rooms. Room can't be avoided and waypoint at once
+// Ant can pass waypoint several times, it must pass all waypoints in their order before end room
+// Waypoints can't be used with colonies and foraging
+
+// SetOptions - removes tunnels of avoided rooms and sets rules of capacity, checks waypoints, paths, objective
+// and local search, prepares paths and seed of the run. Options are passed to Match after it
+func (a *anthive) SetOptions(o *Options) error {
+	if o.Edges {
+		if err := a.setEdgeDisjoint(); err != nil {
+			return err
+		}
+	}
+	avoided := make(map[string]bool)
+	var avoid []string
+	for _, name := range o.Avoid {
+		r := a.Rooms[name]
+		if r == nil {
+			return fmt.Errorf("unknown avoided room '%v'", name)
+		} else if a.isStart(name) || a.isEnd(name) {
+			return fmt.Errorf("start and end rooms can't be avoided: '%v'", name)
+		} else if avoided[name] {
+			continue
+		}
+		avoided[name] = true
+		avoid = append(avoid, name)
+		for _, l := range append([][2]*room{}, a.Links...) {
+			if l[0] == r || l[1] == r {
+				a.removeLink(l[0], l[1])
+			}
+		}
+	}
+	for _, name := range o.Waypoints {
+		if a.Rooms[name] == nil {
+			return fmt.Errorf("unknown waypoint '%v'", name)
+		} else if a.isStart(name) || a.isEnd(name) {
+			return fmt.Errorf("start and end rooms can't be waypoints: '%v'", name)
+		} else if avoided[name] {
+			return fmt.Errorf("room '%v' can't be avoided and waypoint at once", name)
+		}
+	}
+	if len(o.Waypoints) > 0 && len(a.Colonies) > 0 {
+		return errors.New("waypoints can't be used with colonies")
+	} else if len(o.Waypoints) > 0 && a.Foraging {
+		return errors.New("waypoints can't be used with foraging")
+	}
+	o.Avoid = avoid
+	o.pinned = nil
+	if o.Paths != nil {
+		if err := a.setPaths(o); err != nil {
+			return err
+		}
+	}
+	if err := a.validateObjective(o); err != nil {
+		return err
+	}
+	if o.Improve {
+		if err := a.validateImprove(o); err != nil {
+			return err
+		}
+	}
+	a.shuffle(o)
+	return nil
+}
+
+// matchWaypoints - finds paths through waypoints one by one and sends ants by them with timetable
+func (a *anthive) matchWaypoints(o *Options) error {
+	rt := a.newRouter(o)
+	penalty := make([]int, len(a.RoomsOrder))
+	// rooms of previous paths are more expensive than any path without them
+	reuse := 1
+	for _, l := range a.Links {
+		reuse += l[0].length(l[1])
+	}
+	routes := make(map[string][][]int)
+	var paths []*list
+	steps := 0
+	for count := 0; count < a.AntsCount; count++ {
+		added := false
+		for _, group := range append([]string{""}, a.Starts...) {
+			if ants, ok := a.StartAnts[group]; group == "" && a.freeAnts() == 0 || group != "" && (!ok || ants == 0) {
+				continue
+			}
+			route := a.waypointRoute(o, rt, group, penalty, reuse)
+			// path which is longer than the best count of turns can't bring ants earlier
+			if route == nil || hasRoute(routes[group], route) || steps > 0 && a.routeList(route).Dist >= steps {
+				continue
+			}
+			routes[group] = append(routes[group], route)
+			for _, i := range route[1 : len(route)-1] {
+				penalty[i] += reuse
+			}
+			added = true
+		}
+		if !added {
+			break
+		}
+		groups := make(map[string][]*list)
+		for group, groupRoutes := range routes {
+			for _, route := range groupRoutes {
+				groups[group] = append(groups[group], a.routeList(route))
+			}
+		}
+		if curPaths, curSteps := a.scheduleAnts(o, groups); curSteps > 0 && (steps == 0 || curSteps < steps) {
+			paths, steps = curPaths, curSteps
+		}
+	}
+	if steps == 0 {
+		return errors.New("path not found")
+	}
+	a.StepsCount = steps
+	a.Result.Paths = paths
+	a.Result.Map = a.Map()
+	return nil
+}
+
+// waypointRoute - returns indexes of rooms of the cheapest path of the group from its start room (the first room)
+// through waypoints to end room, nil if there is no such path. Rooms have extra cost by penalty,
+// and rooms of previous parts of the path have extra cost reuse
+func (a *anthive) waypointRoute(o *Options, rt *router, group string, penalty []int, reuse int) []int {
+	starts, ends := a.groupRooms(group)
+	var from []int
+	for i, r := range a.RoomsOrder {
+		if starts[r] {
+			from = append(from, i)
+		}
+	}
+	used := make([]int, len(a.RoomsOrder))
+	var route []int
+	for part := 0; part <= len(o.Waypoints); part++ {
+		target := func(i int) bool { return ends[a.RoomsOrder[i]] }
+		if part < len(o.Waypoints) {
+			waypoint := rt.Index[a.Rooms[o.Waypoints[part]]]
+			target = func(i int) bool { return i == waypoint }
+		}
+		rooms := a.cheapestPart(rt, from, target, func(i int) int { return penalty[i] + reuse*used[i] })
+		if rooms == nil {
+			return nil
+		} else if route == nil {
+			route = rooms
+		} else {
+			route = append(route, rooms[1:]...)
+		}
+		for _, i := range rooms[1:] {
+			used[i]++
+		}
+		from = rooms[len(rooms)-1:]
+	}
+	return route
+}
+
+// cheapestPart - returns indexes of rooms of the cheapest path from one of rooms (the first room) to the target room
+// by lengths of tunnels and extra cost of rooms (label-correcting search), nil if target isn't reachable.
+// Path doesn't go through end rooms
+func (a *anthive) cheapestPart(rt *router, from []int, target func(i int) bool, extra func(i int) int) []int {
+	cost := make([]int, len(a.RoomsOrder))
+	parent := make([]int, len(a.RoomsOrder))
+	inQueue := make([]bool, len(a.RoomsOrder))
+	for i := range cost {
+		cost[i], parent[i] = -1, -1
+	}
+	var queue []int
+	for _, i := range from {
+		cost[i] = 0
+		queue = append(queue, i)
+		inQueue[i] = true
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		inQueue[cur] = false
+		if target(cur) || a.isEnd(a.RoomsOrder[cur].Name) {
+			continue
+		}
+		for _, next := range rt.Adjacent[cur] {
+			nextCost := cost[cur] + a.RoomsOrder[cur].length(a.RoomsOrder[next]) + extra(next)
+			if cost[next] == -1 || nextCost < cost[next] {
+				cost[next], parent[next] = nextCost, cur
+				if !inQueue[next] {
+					inQueue[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	best := -1
+	for i := range a.RoomsOrder {
+		if target(i) && cost[i] >= 0 && (best == -1 || cost[i] < cost[best]) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil
+	}
+	rooms := []int{best}
+	for cur := best; parent[cur] != -1; cur = parent[cur] {
+		rooms = append([]int{parent[cur]}, rooms...)
+	}
+	return rooms
+}
+
+// routeList - returns path by indexes of rooms, the first room is start room
+func (a *anthive) routeList(route []int) *list {
+	path := &list{Start: a.RoomsOrder[route[0]]}
+	prev := path.Start
+	for _, i := range route[1:] {
+		r := a.RoomsOrder[i]
+		path.Dist += prev.length(r)
+		path.PushBack(r)
+		path.Back.Dist = path.Dist
+		prev = r
+	}
+	return path
+}
+
+// hasRoute - returns true if routes have the same route
+func hasRoute(routes [][]int, route []int) bool {
+	for _, other := range routes {
+		if len(other) != len(route) {
+			continue
+		}
+		same := true
+		for i := range route {
+			if other[i] != route[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package anthive
+
+import (
+	"strings"
+	"testing"
+)
+
+const exampleMap = "3\n##start\n1 23 3\n2 16 7\n3 16 3\n4 16 5\n5 9 3\n6 1 5\n7 4 8\n##end\ncool 9 5\ncool-4\n1-3\n4-3\n5-2\n3-5\n4-2\n2-1\n6-cool\n7-6\n7-2\n7-4\n6-5\n"
+
+func TestMatchWaypoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		turns   int
+	}{
+		{"avoided room", Options{Avoid: []string{"3"}}, 5},
+		{"waypoint", Options{Waypoints: []string{"4"}}, 5},
+		{"waypoints in order", Options{Waypoints: []string{"2", "4"}}, 5},
+		{"avoided room and waypoint", Options{Avoid: []string{"2"}, Waypoints: []string{"4"}}, 5},
+	}
+	for _, test := range tests {
+		o := test.options
+		a, moves := solveTest(t, test.name, exampleMap, &o, test.turns)
+		if strings.Join(a.Result.Map.Avoid, ",") != strings.Join(test.options.Avoid, ",") ||
+			strings.Join(a.Result.Map.Waypoints, ",") != strings.Join(test.options.Waypoints, ",") {
+			t.Errorf("%s: map has avoided rooms %v and waypoints %v", test.name, a.Result.Map.Avoid, a.Result.Map.Waypoints)
+		}
+		// every ant passes waypoints in their order and doesn't visit avoided rooms
+		passed := make(map[int]int)
+		for _, step := range moves {
+			for _, move := range step {
+				for _, avoided := range test.options.Avoid {
+					if move.Room == avoided {
+						t.Errorf("%s: ant %d visits avoided room '%s'", test.name, move.Ant, avoided)
+					}
+				}
+				if passed[move.Ant] < len(test.options.Waypoints) && move.Room == test.options.Waypoints[passed[move.Ant]] {
+					passed[move.Ant]++
+				}
+			}
+		}
+		for ant := 1; ant <= a.AntsCount; ant++ {
+			if passed[ant] != len(test.options.Waypoints) {
+				t.Errorf("%s: ant %d passes %d of waypoints %v", test.name, ant, passed[ant], test.options.Waypoints)
+			}
+		}
+	}
+}
+
+func TestSetOptionsErrors(t *testing.T) {
+	colonies := "2\n##colony red 1\n##colony blue 1\n##start red\nr1 0 0\n##end red\nr2 6 0\n##start blue\nb1 0 4\n##end blue\nb2 6 4\na 3 0\nr1-a\na-r2\nb1-a\na-b2\n"
+	tests := []struct {
+		name    string
+		content string
+		options Options
+		err     string
+	}{
+		{"unknown avoided room", exampleMap, Options{Avoid: []string{"x"}}, "unknown avoided room 'x'"},
+		{"unknown waypoint", exampleMap, Options{Waypoints: []string{"x"}}, "unknown waypoint 'x'"},
+		{"avoided start room", exampleMap, Options{Avoid: []string{"1"}}, "start and end rooms can't be avoided: '1'"},
+		{"end room as waypoint", exampleMap, Options{Waypoints: []string{"cool"}}, "start and end rooms can't be waypoints: 'cool'"},
+		{"avoided waypoint", exampleMap, Options{Avoid: []string{"3"}, Waypoints: []string{"3"}}, "room '3' can't be avoided and waypoint at once"},
+		{"waypoint of colonies", colonies, Options{Waypoints: []string{"a"}}, "waypoints can't be used with colonies"},
+	}
+	for _, test := range tests {
+		a := readTest(t, test.name, test.content)
+		o := test.options
+		if err := a.SetOptions(&o); err == nil || err.Error() != test.err {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
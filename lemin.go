@@ -4,15 +4,39 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"leminmod/anthive"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
 )
 
+// Config - settings of reading and solving. Zero value is the classic lem-in
+type Config struct {
+	Input   string          // Format of input: anthive.FORMAT_AUTO (by content) | FORMAT_MAP | FORMAT_DOT | FORMAT_JSON
+	Options anthive.Options // Avoided rooms and waypoints of the run, they aren't written in the map
+	Rules   *anthive.Rules  // Rules of the lem-in variant for reading, writing and checking of moves, nil means anthive.DefaultRules
+	Log     io.Writer       // Improvements of local search and runs are written there, nil means they aren't written
+	// Best-of-N: count of runs of the solver with seeds Options.Seed, Options.Seed+1, ..., the result with the least count of turns is kept.
+	// 0 and 1 mean one run
+	Runs     int
+	Parallel bool // Runs go in parallel
+}
+
+// DefaultConfig - using by package functions
+var DefaultConfig = &Config{}
+
 // RunProgramWithFile - path is filepath,
 // writes result to output. Close program if has error.
 func RunProgramWithFile(path string, showContent bool) {
-	err := WriteResultByFilePath(os.Stdout, path, showContent)
+	DefaultConfig.RunProgramWithFile(path, showContent)
+}
+
+// RunProgramWithFile - path is filepath,
+// writes result to output. Close program if has error.
+func (c *Config) RunProgramWithFile(path string, showContent bool) {
+	err := c.WriteResultByFilePath(os.Stdout, path, showContent)
 	if err != nil {
 		fmt.Printf("ERROR: %v\n", err.Error())
 		os.Exit(1)
@@ -21,27 +45,22 @@ func RunProgramWithFile(path string, showContent bool) {
 
 // WriteResultByFilePath - path is filepath.
 func WriteResultByFilePath(w io.Writer, path string, writeContent bool) error {
-	file, err := os.Open(path)
-	defer file.Close()
+	return DefaultConfig.WriteResultByFilePath(w, path, writeContent)
+}
+
+// WriteResultByFilePath - path is filepath.
+func (c *Config) WriteResultByFilePath(w io.Writer, path string, writeContent bool) error {
+	content, err := readFile(path)
 	if err != nil {
 		return fmt.Errorf("WriteResultByFilePath: %w", err)
-	} else if fInfo, _ := file.Stat(); fInfo.IsDir() {
-		err = fmt.Errorf("%v is directory", fInfo.Name())
-		return fmt.Errorf("WriteResultByFilePath: %w", err)
 	}
-
-	scanner := bufio.NewScanner(file)
-	result, err := GetResult(scanner)
+	result, err := c.getResult(content)
 	if err != nil {
 		return fmt.Errorf("WriteResultByFilePath: %w", err)
 	}
 	if writeContent {
-		file.Seek(0, io.SeekStart)
-		_, err = io.Copy(w, file)
-		if err != nil {
-			return fmt.Errorf("WriteResultByFilePath: %w", err)
-		}
-		fmt.Fprint(w, "\n\n# result\n")
+		fmt.Fprint(w, content)
+		fmt.Fprint(w, "\n\n"+resultComment+"\n")
 	}
 	result.WriteResult(w)
 
@@ -51,8 +70,13 @@ func WriteResultByFilePath(w io.Writer, path string, writeContent bool) error {
 // WriteResultByContent - using for Web,
 //inputs writer for write result, writes nothing if returns error
 func WriteResultByContent(w io.Writer, content string, writeContent bool) error {
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	result, err := GetResult(scanner)
+	return DefaultConfig.WriteResultByContent(w, content, writeContent)
+}
+
+// WriteResultByContent - using for Web,
+//inputs writer for write result, writes nothing if returns error
+func (c *Config) WriteResultByContent(w io.Writer, content string, writeContent bool) error {
+	result, err := c.getResult(content)
 	if err != nil {
 		return fmt.Errorf("WriteResultByContent: %w", err)
 	}
@@ -65,6 +89,39 @@ func WriteResultByContent(w io.Writer, content string, writeContent bool) error
 	return nil
 }
 
+// openFile - opens file for reading, returns an error if path is directory
+func openFile(path string) (*os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	} else if fInfo, _ := file.Stat(); fInfo.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("%v is directory", fInfo.Name())
+	}
+	return file, nil
+}
+
+// readFile - returns content of file
+func readFile(path string) (string, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	return string(content), err
+}
+
+// readContent - returns all lines of scanner
+func readContent(scanner *bufio.Scanner) (string, error) {
+	var b strings.Builder
+	for scanner.Scan() {
+		b.WriteString(scanner.Text())
+		b.WriteByte('\n')
+	}
+	return b.String(), scanner.Err()
+}
+
 func errInvalidDataFormat(err error) error {
 	return fmt.Errorf("invalid data format, %s", err)
 }
@@ -72,25 +129,397 @@ func errInvalidDataFormat(err error) error {
 // GetResult - returns result,
 //nil if shortest disjoint paths was found
 func GetResult(scanner *bufio.Scanner) (*anthive.Result, error) {
-	terrain := anthive.Createanthive()
-	var err error
-	for scanner.Scan() {
-		err = terrain.ReadDataFromLine(scanner.Text())
-		if err != nil {
-			return nil, errInvalidDataFormat(err)
+	return DefaultConfig.GetResult(scanner)
+}
+
+// GetResult - returns result,
+//nil if shortest disjoint paths was found
+func (c *Config) GetResult(scanner *bufio.Scanner) (*anthive.Result, error) {
+	content, err := readContent(scanner)
+	if err != nil {
+		return nil, err
+	}
+	return c.getResult(content)
+}
+
+func (c *Config) getResult(content string) (*anthive.Result, error) {
+	return c.solve(content, c.Input)
+}
+
+// solve - returns result of the best run of the solver (the least count of turns, then the least seed)
+func (c *Config) solve(content, format string) (*anthive.Result, error) {
+	runs := c.Runs
+	if runs < 1 {
+		runs = 1
+	}
+	results, errs := make([]*anthive.Result, runs), make([]error, runs)
+	if c.Parallel {
+		var wg sync.WaitGroup
+		limit := make(chan struct{}, runtime.NumCPU())
+		for i := 0; i < runs; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				limit <- struct{}{}
+				results[i], errs[i] = c.run(content, format, c.Options.Seed+int64(i))
+				<-limit
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := 0; i < runs; i++ {
+			results[i], errs[i] = c.run(content, format, c.Options.Seed+int64(i))
+		}
+	}
+	var all []anthive.Run
+	for i, result := range results {
+		// errors of reading and options are the same for all runs
+		if errs[i] != nil {
+			return nil, errs[i]
 		}
+		all = append(all, anthive.Run{Seed: c.Options.Seed + int64(i), Turns: result.Turns()})
+	}
+	best := anthive.BestRun(all)
+	c.logImprovements(results[best])
+	if runs > 1 && c.Log != nil {
+		anthive.WriteRuns(c.Log, all, all[best])
 	}
-	err = terrain.ValidateByFieldInfo()
+	return results[best], nil
+}
+
+// run - returns result of one run of the solver with the seed
+func (c *Config) run(content, format string, seed int64) (*anthive.Result, error) {
+	terrain, err := anthive.ReadAnthiveByRules(content, format, c.rules())
 	if err != nil {
 		return nil, errInvalidDataFormat(err)
 	}
-	err = terrain.Match()
+	options := c.Options
+	options.Seed = seed
+	err = terrain.SetOptions(&options)
+	if err != nil {
+		return nil, errOptions(err)
+	}
+	err = terrain.Match(&options)
 	if err != nil {
 		return nil, errPaths(err)
 	}
 	return terrain.Result, nil
 }
 
+// logImprovements - writes improvements of local search of the result into log
+func (c *Config) logImprovements(result *anthive.Result) {
+	if c.Log != nil {
+		result.WriteImprovements(c.Log)
+	}
+}
+
 func errPaths(err error) error {
 	return fmt.Errorf("path error, %s", err)
 }
+
+func errOptions(err error) error {
+	return fmt.Errorf("options error, %s", err)
+}
+
+// GetResultByFilePath - returns result of the map from file
+func GetResultByFilePath(path string) (*anthive.Result, error) {
+	return DefaultConfig.GetResultByFilePath(path)
+}
+
+// GetResultByFilePath - returns result of the map from file
+func (c *Config) GetResultByFilePath(path string) (*anthive.Result, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("GetResultByFilePath: %w", err)
+	}
+	result, err := c.getResult(content)
+	if err != nil {
+		return nil, fmt.Errorf("GetResultByFilePath: %w", err)
+	}
+	return result, nil
+}
+
+// GetMap - returns the map without searching of paths
+func GetMap(scanner *bufio.Scanner) (*anthive.Map, error) {
+	return DefaultConfig.GetMap(scanner)
+}
+
+// GetMap - returns the map without searching of paths
+func (c *Config) GetMap(scanner *bufio.Scanner) (*anthive.Map, error) {
+	content, err := readContent(scanner)
+	if err != nil {
+		return nil, err
+	}
+	return c.getMap(content)
+}
+
+func (c *Config) getMap(content string) (*anthive.Map, error) {
+	terrain, err := anthive.ReadAnthiveByRules(content, c.Input, c.rules())
+	if err != nil {
+		return nil, errInvalidDataFormat(err)
+	}
+	options := c.Options
+	err = terrain.SetOptions(&options)
+	if err != nil {
+		return nil, errOptions(err)
+	}
+	m := terrain.Map()
+	m.SetOptions(options)
+	return m, nil
+}
+
+// GetMapByFilePath - returns the map from file without searching of paths
+func GetMapByFilePath(path string) (*anthive.Map, error) {
+	return DefaultConfig.GetMapByFilePath(path)
+}
+
+// GetMapByFilePath - returns the map from file without searching of paths
+func (c *Config) GetMapByFilePath(path string) (*anthive.Map, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("GetMapByFilePath: %w", err)
+	}
+	m, err := c.getMap(content)
+	if err != nil {
+		return nil, fmt.Errorf("GetMapByFilePath: %w", err)
+	}
+	return m, nil
+}
+
+// GetReplay - returns map and moves of ants.
+// Moves are read after the map (lines started with 'L'), if there are no moves then map is solved
+func GetReplay(scanner *bufio.Scanner) (*anthive.Map, [][]anthive.Move, error) {
+	return DefaultConfig.GetReplay(scanner)
+}
+
+// GetReplay - returns map and moves of ants.
+// Moves are read after the map (lines started with 'L'), if there are no moves then map is solved
+func (c *Config) GetReplay(scanner *bufio.Scanner) (*anthive.Map, [][]anthive.Move, error) {
+	content, err := readContent(scanner)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.getReplay(content, "")
+}
+
+// getReplay - moves are read from movesContent, or after the map if movesContent is empty
+func (c *Config) getReplay(content, movesContent string) (*anthive.Map, [][]anthive.Move, error) {
+	format := c.Input
+	if format == anthive.FORMAT_AUTO || format == "" {
+		format = anthive.DetectFormat(content)
+	}
+	var moves [][]anthive.Move
+	var err error
+	if movesContent != "" {
+		_, moves, err = c.splitMoves(movesContent, true)
+	} else if format == anthive.FORMAT_MAP {
+		content, moves, err = c.splitMoves(content, false)
+	}
+	if err != nil {
+		return nil, nil, errMoves(err)
+	}
+	if moves == nil {
+		result, err := c.solve(content, format)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Map, result.Moves(), nil
+	}
+	terrain, err := anthive.ReadAnthiveByRules(content, format, c.rules())
+	if err != nil {
+		return nil, nil, errInvalidDataFormat(err)
+	}
+	options := c.Options
+	err = terrain.SetOptions(&options)
+	if err != nil {
+		return nil, nil, errOptions(err)
+	}
+	m := terrain.Map()
+	m.SetOptions(options)
+	err = m.Verify(moves)
+	if err != nil {
+		return nil, nil, errMoves(err)
+	}
+	return m, moves, nil
+}
+
+// rules - returns rules of the lem-in variant of the config
+func (c *Config) rules() anthive.Rules {
+	if c.Rules == nil {
+		return anthive.DefaultRules
+	}
+	return *c.Rules
+}
+
+// resultComment - comment between the map and moves which are written after it
+const resultComment = "# result"
+
+// splitMoves - separates lines of moves (started with 'L') from the map.
+// Empty lines between moves are steps without moves (ants are in long tunnels or wait for closed places).
+// Empty lines before the first move are steps too if content has only moves or they follow resultComment
+func (c *Config) splitMoves(content string, onlyMoves bool) (string, [][]anthive.Move, error) {
+	var b strings.Builder
+	var moves [][]anthive.Move
+	empty := 0
+	leading := onlyMoves // empty lines before the first move are steps
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "L") {
+			step, err := c.rules().ParseMoves(line)
+			if err != nil {
+				return "", nil, err
+			}
+			for ; empty > 0; empty-- {
+				moves = append(moves, []anthive.Move{})
+			}
+			moves = append(moves, step)
+			empty = 0
+			continue
+		} else if moves != nil && line != "" {
+			return "", nil, fmt.Errorf("unexpected line after moves: '%v'", line)
+		} else if moves != nil || leading && line == "" {
+			empty++
+			continue
+		}
+		empty = 0
+		leading = onlyMoves || line == resultComment
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String(), moves, nil
+}
+
+func errMoves(err error) error {
+	return fmt.Errorf("moves error, %s", err)
+}
+
+// GetReplayByFilePath - returns map and moves from files. movesPath is optional,
+// if it's empty then moves are read from the map file (or map is solved)
+func GetReplayByFilePath(mapPath, movesPath string) (*anthive.Map, [][]anthive.Move, error) {
+	return DefaultConfig.GetReplayByFilePath(mapPath, movesPath)
+}
+
+// GetReplayByFilePath - returns map and moves from files. movesPath is optional,
+// if it's empty then moves are read from the map file (or map is solved)
+func (c *Config) GetReplayByFilePath(mapPath, movesPath string) (*anthive.Map, [][]anthive.Move, error) {
+	content, err := readFile(mapPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetReplayByFilePath: %w", err)
+	}
+	movesContent := ""
+	if movesPath != "" {
+		if movesContent, err = readFile(movesPath); err != nil {
+			return nil, nil, fmt.Errorf("GetReplayByFilePath: %w", err)
+		}
+	}
+	m, moves, err := c.getReplay(content, movesContent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetReplayByFilePath: %w", err)
+	}
+	return m, moves, nil
+}
+
+// GetScenarioByFilePath - returns scenario of the map from file with events from file:
+// the first plan and plans of remaining ants after events
+func GetScenarioByFilePath(mapPath, eventsPath string) (*anthive.Scenario, error) {
+	return DefaultConfig.GetScenarioByFilePath(mapPath, eventsPath)
+}
+
+// GetScenarioByFilePath - returns scenario of the map from file with events from file:
+// the first plan and plans of remaining ants after events
+func (c *Config) GetScenarioByFilePath(mapPath, eventsPath string) (*anthive.Scenario, error) {
+	content, err := readFile(mapPath)
+	if err != nil {
+		return nil, fmt.Errorf("GetScenarioByFilePath: %w", err)
+	}
+	eventsContent, err := readFile(eventsPath)
+	if err != nil {
+		return nil, fmt.Errorf("GetScenarioByFilePath: %w", err)
+	}
+	s, err := c.getScenario(content, eventsContent)
+	if err != nil {
+		return nil, fmt.Errorf("GetScenarioByFilePath: %w", err)
+	}
+	return s, nil
+}
+
+func (c *Config) getScenario(content, eventsContent string) (*anthive.Scenario, error) {
+	events, err := anthive.ParseEvents(eventsContent)
+	if err != nil {
+		return nil, errEvents(err)
+	}
+	terrain, err := anthive.ReadAnthiveByRules(content, c.Input, c.rules())
+	if err != nil {
+		return nil, errInvalidDataFormat(err)
+	}
+	options := c.Options
+	err = terrain.SetOptions(&options)
+	if err != nil {
+		return nil, errOptions(err)
+	}
+	err = terrain.Match(&options)
+	if err != nil {
+		return nil, errPaths(err)
+	}
+	s, err := terrain.Simulate(events, &options)
+	if err != nil {
+		return nil, errEvents(err)
+	}
+	return s, nil
+}
+
+func errEvents(err error) error {
+	return fmt.Errorf("events error, %s", err)
+}
+
+// GetThroughputByFilePath - returns steady state of the infinite stream of ants for the map from file
+func GetThroughputByFilePath(path string) (*anthive.Throughput, error) {
+	return DefaultConfig.GetThroughputByFilePath(path)
+}
+
+// GetThroughputByFilePath - returns steady state of the infinite stream of ants for the map from file
+func (c *Config) GetThroughputByFilePath(path string) (*anthive.Throughput, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("GetThroughputByFilePath: %w", err)
+	}
+	terrain, err := anthive.ReadAnthiveByRules(content, c.Input, c.rules())
+	if err != nil {
+		return nil, fmt.Errorf("GetThroughputByFilePath: %w", errInvalidDataFormat(err))
+	}
+	options := c.Options
+	err = terrain.SetOptions(&options)
+	if err != nil {
+		return nil, fmt.Errorf("GetThroughputByFilePath: %w", errOptions(err))
+	}
+	t, err := terrain.Throughput(&options)
+	if err != nil {
+		return nil, fmt.Errorf("GetThroughputByFilePath: %w", errPaths(err))
+	}
+	return t, nil
+}
+
+// GetObjectivesByFilePath - returns costs of results of the map from file for every objective (anthive.Objectives)
+func GetObjectivesByFilePath(path string) ([]anthive.Costs, error) {
+	return DefaultConfig.GetObjectivesByFilePath(path)
+}
+
+// GetObjectivesByFilePath - returns costs of results of the map from file for every objective (anthive.Objectives).
+// Objective of config options is ignored
+func (c *Config) GetObjectivesByFilePath(path string) ([]anthive.Costs, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("GetObjectivesByFilePath: %w", err)
+	}
+	var result []anthive.Costs
+	for _, objective := range anthive.Objectives {
+		config := *c
+		config.Options.Objective = objective
+		r, err := config.getResult(content)
+		if err != nil {
+			return nil, fmt.Errorf("GetObjectivesByFilePath: %w", err)
+		}
+		result = append(result, r.Costs())
+	}
+	return result, nil
+}
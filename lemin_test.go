@@ -0,0 +1,44 @@
+package leminmod
+
+import (
+	"bytes"
+	"fmt"
+	"leminmod/anthive"
+	"testing"
+)
+
+const exampleMap = "3\n##start\n1 23 3\n2 16 7\n3 16 3\n4 16 5\n5 9 3\n6 1 5\n7 4 8\n##end\ncool 9 5\ncool-4\n1-3\n4-3\n5-2\n3-5\n4-2\n2-1\n6-cool\n7-6\n7-2\n7-4\n6-5\n"
+
+func TestSolveRuns(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		log    string
+	}{
+		{"one run", Config{Options: anthive.Options{Seed: 1}}, ""},
+		{"runs", Config{Options: anthive.Options{Seed: 1}, Runs: 4}, "runs: 4, 4 turns: 4, best: seed 1 with 4 turns\n"},
+		{"parallel runs", Config{Options: anthive.Options{Seed: 1}, Runs: 4, Parallel: true}, "runs: 4, 4 turns: 4, best: seed 1 with 4 turns\n"},
+	}
+	for _, test := range tests {
+		var log bytes.Buffer
+		c := test.config
+		c.Log = &log
+		result, err := c.getResult(exampleMap)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		// the kept result is the result of the best seed
+		single, err := c.run(exampleMap, c.Input, c.Options.Seed)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if result.Turns() != single.Turns() || fmt.Sprint(result.Moves()) != fmt.Sprint(single.Moves()) {
+			t.Errorf("%s: result with %d turns differs from run of seed %d with %d turns", test.name, result.Turns(), c.Options.Seed, single.Turns())
+		}
+		if log.String() != test.log {
+			t.Errorf("%s: log %q, want %q", test.name, log.String(), test.log)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"leminmod/anthive"
+	"leminmod/visual"
+	"os"
+)
+
+// runConvert - lem-in convert: writes the map in another format
+func runConvert(args []string) {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := flags.String("to", "", "output format: map | dot")
+	paths := flags.Bool("paths", false, "dot: solve the map and color edges of found paths")
+	output := flags.String("o", "", "output file (default: stdout)")
+	config := configFlags(flags)
+	parseCommand(flags, args, "--to=map|dot [flags] filename", 1, 1)
+
+	var m *anthive.Map
+	var routes [][]string
+	var starts []string
+	var err error
+	if *paths {
+		var result *anthive.Result
+		result, err = config.GetResultByFilePath(flags.Arg(0))
+		if err == nil {
+			m, routes, starts = result.Map, result.Routes(), result.Starts()
+		}
+	} else {
+		m, err = config.GetMapByFilePath(flags.Arg(0))
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var write func(w io.Writer) error
+	switch *to {
+	case anthive.FORMAT_MAP:
+		write = m.WriteMap
+	case anthive.FORMAT_DOT:
+		write = func(w io.Writer) error {
+			return visual.WriteDOT(w, m, routes, starts)
+		}
+	default:
+		fmt.Fprintf(flags.Output(), "ERROR: unknown output format '%v'\n", *to)
+		flags.Usage()
+		os.Exit(1)
+	}
+	if err = writeOutput(*output, write); err != nil {
+		exitWithError(err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"leminmod/anthive"
+	"os"
+)
+
+// runFmt - lem-in fmt: writes maps in canonical form
+func runFmt(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "don't write maps, fail if some of them are not formatted (their names are printed)")
+	write := flags.Bool("w", false, "write result to the source file instead of stdout")
+	parseCommand(flags, args, "[--check | -w] filename...", 1, -1)
+
+	unformatted := false
+	for _, path := range flags.Args() {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			exitWithError(err)
+		}
+		formatted, err := anthive.Format(string(content))
+		if err != nil {
+			exitWithError(fmt.Errorf("%v: %w", path, err))
+		}
+		switch {
+		case *check:
+			if formatted != string(content) {
+				fmt.Println(path)
+				unformatted = true
+			}
+		case *write:
+			if formatted != string(content) {
+				if err = ioutil.WriteFile(path, []byte(formatted), 0644); err != nil {
+					exitWithError(err)
+				}
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	if unformatted {
+		os.Exit(1)
+	}
+}
@@ -1,31 +1,159 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"leminmod"
+	"leminmod/anthive"
 	"os"
 	"strings"
 )
 
+// commands - subcommands of program: lem-in <command> [flags] args
+var commands = map[string]func(args []string){
+	"convert":    runConvert,
+	"fmt":        runFmt,
+	"objectives": runObjectives,
+	"play":       runPlay,
+	"render":     runRender,
+	"scenario":   runScenario,
+	"throughput": runThroughput,
+}
+
 func main() {
-	if len(os.Args) != 2 {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+	if len(os.Args) < 2 {
 		fmt.Println("ERROR: Program takes argument (fileName or --flags)!")
 		os.Exit(1)
 	}
-	argument := os.Args[1]
 	// Set Flags
 	filename := flag.String("file", "", "--file=filename\n")
+	config := configFlags(flag.CommandLine)
 	flag.Parse()
 	// Start Program
-	if strings.HasPrefix(argument, "--") { // if has flags
-		if *filename != "" {
-			leminmod.RunProgramWithFile(*filename, true)
-		} else { // Default = Help
-			flag.Usage()
-			os.Exit(1)
+	if flag.NArg() > 1 {
+		fmt.Println("ERROR: Program takes argument (fileName or --flags)!")
+		os.Exit(1)
+	} else if flag.NArg() == 1 && *filename == "" { // Default
+		config.RunProgramWithFile(flag.Arg(0), false)
+	} else if flag.NArg() == 0 && *filename != "" { // if has flags
+		config.RunProgramWithFile(*filename, true)
+	} else { // Default = Help
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+// configFlags - adds flags of leminmod.Config into flags
+func configFlags(flags *flag.FlagSet) *leminmod.Config {
+	config := &leminmod.Config{Log: os.Stderr}
+	flags.StringVar(&config.Input, "input", anthive.FORMAT_AUTO, "--input=auto|map|dot|json - format of input\n")
+	flags.Var(&rulesFlag{config: config}, "rules", "--rules=default|strict-42|lenient|rule1,rule2 - rules of the lem-in variant, custom rules are allowed rules:\n"+
+		"duplicate-links, unknown-commands, trailing-spaces, hash-names, negative-coords\n")
+	flags.Var((*roomsFlag)(&config.Options.Avoid), "avoid", "--avoid=room1,room2 - rooms which ants can't visit\n")
+	flags.Var((*roomsFlag)(&config.Options.Waypoints), "via", "--via=room1,room2 - rooms which every ant passes in this order\n")
+	flags.Var(&pathsFlag{options: &config.Options}, "path-set", "--path-set=filename - ants take exactly these paths (one path s-a-b-e in every line)\n")
+	flags.Var(&pathsFlag{options: &config.Options, fill: true}, "pin", "--pin=filename - ants take these paths and the solver adds other paths\n")
+	flags.BoolVar(&config.Options.Improve, "improve", false, "--improve - local search changes found paths while it reduces count of turns\n")
+	flags.BoolVar(&config.Options.Edges, "edge-disjoint", false, "--edge-disjoint - rooms have no limit of ants, every tunnel takes one ant per turn\n")
+	flags.Int64Var(&config.Options.Seed, "seed", 0, "--seed=N - seed of tie-breaking of the solver, the same seed gives the same paths, 0 doesn't shuffle\n")
+	flags.IntVar(&config.Runs, "runs", 1, "--runs=N - runs of the solver with seeds seed..seed+N-1, the result with the least count of turns is kept\n")
+	flags.BoolVar(&config.Parallel, "parallel", false, "--parallel - runs go in parallel\n")
+	flags.StringVar(&config.Options.Objective, "objective", anthive.OBJECTIVE_TURNS, "--objective=turns|moves|wait|turns-moves - what the solver minimizes\n")
+	return config
+}
+
+// pathsFlag - file of paths which are added into options
+type pathsFlag struct {
+	options *anthive.Options
+	fill    bool // the solver adds other paths
+}
+
+func (f *pathsFlag) String() string {
+	return ""
+}
+
+func (f *pathsFlag) Set(value string) error {
+	content, err := ioutil.ReadFile(value)
+	if err != nil {
+		return err
+	}
+	paths, err := anthive.ParsePaths(string(content))
+	if err != nil {
+		return err
+	}
+	// exact paths can't be filled by the solver
+	if f.options.Paths != nil && f.options.Fill != f.fill {
+		return errors.New("--path-set and --pin can't be used together")
+	}
+	f.options.Paths = append(f.options.Paths, paths...)
+	f.options.Fill = f.fill
+	return nil
+}
+
+// rulesFlag - preset of rules or allowed rules separated by commas
+type rulesFlag struct {
+	config *leminmod.Config
+}
+
+func (f *rulesFlag) String() string {
+	if f.config == nil || f.config.Rules == nil {
+		return ""
+	}
+	return f.config.Rules.Name
+}
+
+func (f *rulesFlag) Set(value string) error {
+	rules, err := anthive.ParseRules(value)
+	if err != nil {
+		return err
+	}
+	f.config.Rules = &rules
+	return nil
+}
+
+// roomsFlag - names of rooms separated by commas, flag can be repeated
+type roomsFlag []string
+
+func (f *roomsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *roomsFlag) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*f = append(*f, name)
 		}
-	} else { // Default
-		leminmod.RunProgramWithFile(argument, false)
+	}
+	return nil
+}
+
+// exitWithError - prints error and closes program
+func exitWithError(err error) {
+	fmt.Printf("ERROR: %v\n", err.Error())
+	os.Exit(1)
+}
+
+// parseCommand - parses flags of subcommand, closes program with usage if count of arguments is wrong.
+// maxArgs < 0 means unlimited count of arguments
+func parseCommand(flags *flag.FlagSet, args []string, usage string, minArgs, maxArgs int) {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lem-in %s %s\n", flags.Name(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < minArgs || maxArgs >= 0 && flags.NArg() > maxArgs {
+		flags.Usage()
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathsFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lemin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	paths := filepath.Join(dir, "paths.txt")
+	if err = ioutil.WriteFile(paths, []byte("1-3-4-cool\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		args  []string
+		paths int
+		fill  bool
+		err   string
+	}{
+		{"path sets", []string{"--path-set=" + paths, "--path-set=" + paths}, 2, false, ""},
+		{"pinned paths", []string{"--pin=" + paths, "--pin=" + paths}, 2, true, ""},
+		{"path set and pinned paths", []string{"--path-set=" + paths, "--pin=" + paths}, 0, false,
+			"invalid value \"" + paths + "\" for flag -pin: --path-set and --pin can't be used together"},
+		{"pinned paths and path set", []string{"--pin=" + paths, "--path-set=" + paths}, 0, false,
+			"invalid value \"" + paths + "\" for flag -path-set: --path-set and --pin can't be used together"},
+	}
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+		config := configFlags(flags)
+		err := flags.Parse(test.args)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(config.Options.Paths) != test.paths || config.Options.Fill != test.fill {
+			t.Errorf("%s: %d paths (fill %v), want %d paths (fill %v)", test.name, len(config.Options.Paths), config.Options.Fill, test.paths, test.fill)
+		}
+	}
+}
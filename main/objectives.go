@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"leminmod/anthive"
+	"os"
+)
+
+// runObjectives - lem-in objectives: costs of results for every objective side by side
+// (turns, sum of lengths of paths of all ants, max waiting in start room)
+func runObjectives(args []string) {
+	flags := flag.NewFlagSet("objectives", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "write result as json")
+	config := configFlags(flags)
+	parseCommand(flags, args, "[flags] filename", 1, 1)
+
+	costs, err := config.GetObjectivesByFilePath(flags.Arg(0))
+	if err != nil {
+		exitWithError(err)
+	}
+	if *asJSON {
+		err = anthive.WriteCostsJSON(os.Stdout, costs)
+	} else {
+		err = anthive.WriteCosts(os.Stdout, costs)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
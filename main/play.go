@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"leminmod/visual"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// runPlay - lem-in play: animates the colony on terminal
+func runPlay(args []string) {
+	flags := flag.NewFlagSet("play", flag.ExitOnError)
+	moves := flags.String("moves", "", "file with moves of ants (default: moves after the map, or the map is solved)")
+	delay := flags.Duration("delay", 500*time.Millisecond, "delay between steps")
+	width := flags.Int("width", 80, "width of grid in characters")
+	height := flags.Int("height", 20, "height of grid in characters")
+	label := flags.Int("label", 6, "max length of room names on grid")
+	plain := flags.Bool("plain", false, "without colors, clearing of screen and controls")
+	config := configFlags(flags)
+	parseCommand(flags, args, "[flags] filename", 1, 1)
+
+	m, steps, err := config.GetReplayByFilePath(flags.Arg(0), *moves)
+	if err != nil {
+		exitWithError(err)
+	}
+	player := &visual.Player{
+		Map:        m,
+		Moves:      steps,
+		Width:      *width,
+		Height:     *height,
+		LabelWidth: *label,
+		Delay:      *delay,
+		Plain:      *plain || !visual.IsTerminal(os.Stdout),
+	}
+	var keys <-chan byte
+	restore := func() {}
+	if !player.Plain && visual.IsTerminal(os.Stdin) {
+		keys, restore, err = visual.ReadKeys(os.Stdin)
+		if err != nil {
+			exitWithError(err)
+		}
+		// terminal must be restored on Ctrl+C too
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			restore()
+			os.Exit(1)
+		}()
+	}
+	err = player.Play(os.Stdout, keys)
+	// exitWithError doesn't run deferred functions, so terminal is restored before it
+	restore()
+	if err != nil {
+		exitWithError(err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"leminmod/visual"
+	"os"
+)
+
+// runRender - lem-in render: draws the solved map into image
+func runRender(args []string) {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	svg := flags.Bool("svg", false, "render svg image with rooms, tunnels and found paths")
+	gif := flags.Bool("gif", false, "render animated gif, every step of the simulation is a frame")
+	html := flags.Bool("html", false, "render single html page which plays back the run (works offline)")
+	output := flags.String("o", "", "output file (default: stdout)")
+	width := flags.Int("width", 800, "width of image in pixels")
+	height := flags.Int("height", 600, "height of image in pixels")
+	moves := flags.String("moves", "", "gif, html: file with moves of ants (default: moves after the map, or the map is solved)")
+	delay := flags.Int("delay", 50, "gif: delay between steps in 100ths of a second")
+	interpolate := flags.Int("interpolate", 0, "gif: count of frames between rooms, 0 - ants jump from room to room")
+	config := configFlags(flags)
+	parseCommand(flags, args, "--svg|--gif|--html [flags] filename", 1, 1)
+
+	formats := 0
+	for _, set := range []bool{*svg, *gif, *html} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		fmt.Fprintln(flags.Output(), "ERROR: only one format of image can be set")
+		flags.Usage()
+		os.Exit(1)
+	}
+	var write func(w io.Writer) error
+	switch {
+	case *svg:
+		result, err := config.GetResultByFilePath(flags.Arg(0))
+		if err != nil {
+			exitWithError(err)
+		}
+		write = func(w io.Writer) error {
+			return visual.WriteSVG(w, result, *width, *height)
+		}
+	case *gif:
+		m, steps, err := config.GetReplayByFilePath(flags.Arg(0), *moves)
+		if err != nil {
+			exitWithError(err)
+		}
+		options := visual.GIFOptions{Width: *width, Height: *height, Delay: *delay, Interpolate: *interpolate}
+		write = func(w io.Writer) error {
+			return visual.WriteGIF(w, m, steps, options)
+		}
+	case *html:
+		m, steps, err := config.GetReplayByFilePath(flags.Arg(0), *moves)
+		if err != nil {
+			exitWithError(err)
+		}
+		write = func(w io.Writer) error {
+			return visual.WriteHTML(w, m, steps)
+		}
+	default:
+		fmt.Fprintln(flags.Output(), "ERROR: format of image is not set")
+		flags.Usage()
+		os.Exit(1)
+	}
+	if err := writeOutput(*output, write); err != nil {
+		exitWithError(err)
+	}
+}
+
+// writeOutput - writes into file by path, or into stdout if path is empty
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
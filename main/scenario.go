@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+// runScenario - lem-in scenario: solves the map, then tunnels collapse and rooms fill in by events
+// and remaining ants are planned again
+func runScenario(args []string) {
+	flags := flag.NewFlagSet("scenario", flag.ExitOnError)
+	final := flags.Bool("final", false, "write only moves of the last plan from the first turn (they can be played with --moves)")
+	config := configFlags(flags)
+	parseCommand(flags, args, "[flags] filename events", 2, 2)
+
+	s, err := config.GetScenarioByFilePath(flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		exitWithError(err)
+	}
+	if *final {
+		s.Map.Rules.WriteMoves(os.Stdout, s.Moves)
+		return
+	}
+	s.WriteScenario(os.Stdout)
+}
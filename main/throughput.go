@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+// runThroughput - lem-in throughput: steady state of the infinite stream of ants
+// (ants per turn, latency of paths and dispatch pattern)
+func runThroughput(args []string) {
+	flags := flag.NewFlagSet("throughput", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "write result as json")
+	config := configFlags(flags)
+	parseCommand(flags, args, "[flags] filename", 1, 1)
+
+	t, err := config.GetThroughputByFilePath(flags.Arg(0))
+	if err != nil {
+		exitWithError(err)
+	}
+	if *asJSON {
+		err = t.WriteJSON(os.Stdout)
+	} else {
+		err = t.WriteText(os.Stdout)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
@@ -0,0 +1,87 @@
+package visual
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"leminmod/anthive"
+)
+
+// WriteDOT - writes the map as Graphviz undirected graph. Start and End rooms have attributes start=true and end=true,
+// coordinates are saved in pos attribute, length of tunnel in len attribute, capacity of room or tunnel in capacity attribute,
+// one-way tunnel has dir=forward, rooms of colony have colony attribute, turns of closed room or tunnel are in closed attribute,
+// arrivals of ants are in arrivals attribute of graph, foraging map has foraging=true attribute of graph.
+// Edges of routes (can be nil) are colored, starts are start rooms of routes
+func WriteDOT(w io.Writer, m *anthive.Map, routes [][]string, starts []string) error {
+	edgeColor := make(map[[2]string]string)
+	for i, route := range routes {
+		prev := starts[i]
+		for _, name := range route {
+			edgeColor[[2]string{prev, name}] = hexColor(pathColor(i))
+			edgeColor[[2]string{name, prev}] = hexColor(pathColor(i))
+			prev = name
+		}
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "graph hive {")
+	fmt.Fprintf(out, "\tants=%d\n", m.AntsCount)
+	if m.Arrivals != nil {
+		fmt.Fprintf(out, "\tarrivals=\"%s\"\n", anthive.JoinArrivals(m.Arrivals))
+	}
+	if m.Foraging {
+		fmt.Fprintln(out, "\tforaging=true")
+	}
+	fmt.Fprintln(out, "\tnode [shape=circle]")
+	for _, r := range m.Rooms {
+		attrs := fmt.Sprintf("pos=\"%d,%d!\"", r.X, r.Y)
+		if m.IsStart(r.Name) {
+			attrs += ", start=true, style=filled, fillcolor=\"#2e7d32\""
+			if ants, ok := m.StartAnts[r.Name]; ok {
+				attrs += fmt.Sprintf(", ants=%d", ants)
+			}
+		} else if m.IsEnd(r.Name) {
+			attrs += ", end=true, style=filled, fillcolor=\"#c62828\""
+		} else if r.Capacity > 1 {
+			attrs += fmt.Sprintf(", capacity=%d, xlabel=\"%d\"", r.Capacity, r.Capacity)
+		}
+		if c := m.RoomColony(r.Name); c != nil {
+			attrs += fmt.Sprintf(", colony=%s", dotID(c.Name))
+		}
+		if r.Closed != nil {
+			attrs += fmt.Sprintf(", closed=\"%s\", style=dashed", anthive.JoinTurns(r.Closed))
+		}
+		fmt.Fprintf(out, "\t%s [%s]\n", dotID(r.Name), attrs)
+	}
+	for _, l := range m.Links {
+		var attrs []string
+		if l.OneWay {
+			attrs = append(attrs, "dir=forward")
+		}
+		if l.Length > 1 {
+			attrs = append(attrs, fmt.Sprintf("len=%d, label=\"%d\"", l.Length, l.Length))
+		}
+		if l.Capacity > 0 {
+			attrs = append(attrs, fmt.Sprintf("capacity=%d", l.Capacity))
+		}
+		if l.Closed != nil {
+			attrs = append(attrs, fmt.Sprintf("closed=\"%s\", style=dashed", anthive.JoinTurns(l.Closed)))
+		}
+		if c, ok := edgeColor[[2]string{l.From, l.To}]; ok {
+			attrs = append(attrs, fmt.Sprintf("color=\"%s\", penwidth=3", c))
+		}
+		fmt.Fprintf(out, "\t%s -- %s", dotID(l.From), dotID(l.To))
+		if attrs != nil {
+			fmt.Fprintf(out, " [%s]", strings.Join(attrs, ", "))
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// dotID - returns quoted identifier of DOT language
+func dotID(name string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(name) + "\""
+}
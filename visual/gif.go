@@ -0,0 +1,169 @@
+package visual
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+
+	"leminmod/anthive"
+)
+
+// Sizes of gif elements
+const (
+	gifMargin     = 20
+	gifRoomRadius = 6
+	gifAntRadius  = 4
+)
+
+// Indexes of gif palette, colors of paths are after them
+const (
+	gifBackground = iota
+	gifTunnel
+	gifRoom
+	gifStart
+	gifEnd
+	gifProgress
+	gifPaths
+)
+
+// GIFOptions - options of animated gif
+type GIFOptions struct {
+	Width, Height int
+	Delay         int // Delay between steps in 100ths of a second
+	Interpolate   int // Count of frames between rooms, 0 - ants jump from room to room
+}
+
+// WriteGIF - draws moves of ants as animated gif, every step of the simulation is a frame
+func WriteGIF(w io.Writer, m *anthive.Map, moves [][]anthive.Move, options GIFOptions) error {
+	palette := color.Palette{
+		color.RGBA{0xff, 0xff, 0xff, 0xff},
+		color.RGBA{0xbb, 0xbb, 0xbb, 0xff},
+		color.RGBA{0x33, 0x33, 0x33, 0xff},
+		color.RGBA{0x2e, 0x7d, 0x32, 0xff},
+		color.RGBA{0xc6, 0x28, 0x28, 0xff},
+		color.RGBA{0x88, 0x88, 0x88, 0xff},
+	}
+	for _, c := range pathColors {
+		palette = append(palette, c)
+	}
+	points := imagePoints(m, options.Width, options.Height, gifMargin)
+	routes := antRoutes(m, moves)
+	rect := image.Rect(0, 0, options.Width, options.Height)
+
+	// background with tunnels and rooms
+	background := image.NewPaletted(rect, palette)
+	for _, l := range m.Links {
+		drawThickLine(background, points[l.From], points[l.To], gifTunnel)
+	}
+	for _, r := range m.Rooms {
+		index := uint8(gifRoom)
+		if m.IsStart(r.Name) {
+			index = gifStart
+		} else if m.IsEnd(r.Name) {
+			index = gifEnd
+		}
+		fillCircle(background, points[r.Name], gifRoomRadius, index)
+		if index == gifRoom {
+			fillCircle(background, points[r.Name], gifRoomRadius-2, gifBackground)
+		}
+	}
+
+	anim := &gif.GIF{}
+	frame := func(pos [][2]float64, progress float64, delay int) {
+		img := image.NewPaletted(rect, palette)
+		copy(img.Pix, background.Pix)
+		for x := 0; x < int(progress*float64(options.Width)); x++ {
+			for y := options.Height - 3; y < options.Height; y++ {
+				img.SetColorIndex(x, y, gifProgress)
+			}
+		}
+		for ant := 1; ant < len(pos); ant++ {
+			fillCircle(img, pos[ant], gifAntRadius, uint8(gifPaths+routes[ant]%len(pathColors)))
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	antPoints := func(names []string) [][2]float64 {
+		result := make([][2]float64, len(names))
+		for i, name := range names {
+			result[i] = points[name]
+		}
+		return result
+	}
+
+	steps := len(moves)
+	names := positions(m, moves, 0)
+	prev := antPoints(names)
+	frame(prev, 0, options.Delay)
+	for step := 1; step <= steps; step++ {
+		for _, move := range moves[step-1] {
+			if move.Ant < len(names) {
+				names[move.Ant] = move.Room
+			}
+		}
+		cur := antPoints(names)
+		for i := 1; i <= options.Interpolate; i++ {
+			t := float64(i) / float64(options.Interpolate+1)
+			between := make([][2]float64, len(cur))
+			for ant := range cur {
+				between[ant] = [2]float64{
+					prev[ant][0] + (cur[ant][0]-prev[ant][0])*t,
+					prev[ant][1] + (cur[ant][1]-prev[ant][1])*t,
+				}
+			}
+			frame(between, (float64(step-1)+t)/float64(steps), options.Delay/(options.Interpolate+1))
+		}
+		delay := options.Delay
+		if step == steps {
+			delay *= 3 // pause before loop
+		}
+		frame(cur, float64(step)/float64(steps), delay)
+		prev = cur
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// fillCircle - draws filled circle with center pt
+func fillCircle(img *image.Paletted, pt [2]float64, radius int, index uint8) {
+	cx, cy := int(pt[0]+0.5), int(pt[1]+0.5)
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.SetColorIndex(cx+x, cy+y, index)
+			}
+		}
+	}
+}
+
+// drawThickLine - draws line with width 2px (Bresenham's line algorithm)
+func drawThickLine(img *image.Paletted, from, to [2]float64, index uint8) {
+	x0, y0 := int(from[0]+0.5), int(from[1]+0.5)
+	x1, y1 := int(to[0]+0.5), int(to[1]+0.5)
+	dx, dy := x1-x0, y1-y0
+	stepX, stepY := 1, 1
+	if dx < 0 {
+		stepX, dx = -1, -dx
+	}
+	if dy < 0 {
+		stepY, dy = -1, -dy
+	}
+	e := dx - dy
+	for {
+		img.SetColorIndex(x0, y0, index)
+		img.SetColorIndex(x0+1, y0, index)
+		img.SetColorIndex(x0, y0+1, index)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 > -dy {
+			e -= dy
+			x0 += stepX
+		}
+		if e2 < dx {
+			e += dx
+			y0 += stepY
+		}
+	}
+}
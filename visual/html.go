@@ -0,0 +1,285 @@
+package visual
+
+import (
+	"encoding/json"
+	"io"
+	"text/template"
+
+	"leminmod/anthive"
+)
+
+// replayData - data of the run embedded into html page as json
+type replayData struct {
+	Ants   int            `json:"ants"`
+	Starts []string       `json:"starts"`
+	Ends   []string       `json:"ends"`
+	Origin []string       `json:"origin"` // start room of each ant, index is ant number
+	Names  []string       `json:"names"`  // name of each ant in result line, index is ant number
+	Rooms  []replayRoom   `json:"rooms"`
+	Links  [][2]string    `json:"links"`
+	OneWay []bool         `json:"oneway"` // one-way links go from 0 to 1 room
+	Paths  []replayPath   `json:"paths"`
+	Routes []int          `json:"routes"` // index of path for each ant, index is ant number
+	Moves  [][]replayMove `json:"moves"`
+	Colors []string       `json:"colors"` // colors of paths
+}
+
+type replayRoom struct {
+	Name string `json:"name"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+}
+
+type replayMove struct {
+	Ant  int    `json:"ant"`
+	Room string `json:"room"`
+}
+
+type replayPath struct {
+	Start string   `json:"start"`
+	Rooms []string `json:"rooms"`
+	Ants  int      `json:"ants"`
+}
+
+// WriteHTML - writes single html page which plays back moves of ants. The page works offline
+func WriteHTML(w io.Writer, m *anthive.Map, moves [][]anthive.Move) error {
+	routes := antRoutes(m, moves)
+	data := &replayData{
+		Ants:   m.AntsCount,
+		Starts: m.Starts,
+		Ends:   m.Ends,
+		Origin: m.AntStarts(moves),
+		Names:  make([]string, m.AntsCount+1),
+		Rooms:  make([]replayRoom, len(m.Rooms)),
+		Links:  make([][2]string, len(m.Links)),
+		OneWay: make([]bool, len(m.Links)),
+		Routes: routes,
+		Moves:  make([][]replayMove, len(moves)),
+	}
+	for ant := 1; ant <= m.AntsCount; ant++ {
+		data.Names[ant] = m.AntName(ant)
+	}
+	for i, r := range m.Rooms {
+		data.Rooms[i] = replayRoom{Name: r.Name, X: r.X, Y: r.Y}
+	}
+	for i, l := range m.Links {
+		data.Links[i] = [2]string{l.From, l.To}
+		data.OneWay[i] = l.OneWay
+	}
+	sequences := make([][]string, m.AntsCount+1)
+	for i, step := range moves {
+		data.Moves[i] = make([]replayMove, len(step))
+		for j, move := range step {
+			data.Moves[i][j] = replayMove{Ant: move.Ant, Room: move.Room}
+			if move.Ant < len(sequences) {
+				sequences[move.Ant] = append(sequences[move.Ant], move.Room)
+			}
+		}
+	}
+	for ant := 1; ant <= m.AntsCount; ant++ {
+		if routes[ant] == len(data.Paths) {
+			data.Paths = append(data.Paths, replayPath{Start: data.Origin[ant], Rooms: sequences[ant]})
+			data.Colors = append(data.Colors, hexColor(pathColor(routes[ant])))
+		}
+		data.Paths[routes[ant]].Ants++
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return htmlTemplate.Execute(w, string(encoded))
+}
+
+var htmlTemplate = template.Must(template.New("replay").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>lem-in replay</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; height: 100vh; }
+#main { flex: 1; display: flex; flex-direction: column; }
+#hive { flex: 1; background: #fff; }
+#controls { padding: 8px; border-top: 1px solid #ccc; display: flex; gap: 8px; align-items: center; }
+#timeline { flex: 1; }
+#side { width: 280px; padding: 8px; border-left: 1px solid #ccc; overflow: auto; font-size: 13px; }
+#moves { font-family: monospace; word-break: break-all; }
+.link { stroke: #bbb; stroke-width: 2; }
+.room { fill: #fff; stroke: #333; stroke-width: 2; cursor: pointer; }
+.room.start { fill: #2e7d32; }
+.room.end { fill: #c62828; }
+.label { font-size: 11px; pointer-events: none; }
+.ant { stroke: #000; stroke-width: 1; cursor: pointer; }
+</style>
+</head>
+<body>
+<div id="main">
+<svg id="hive"><defs><marker id="oneway" viewBox="0 0 10 10" refX="5" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#888"/></marker></defs></svg>
+<div id="controls">
+<button id="play">play</button>
+<button id="prev">&lt;</button>
+<button id="next">&gt;</button>
+<input id="timeline" type="range" min="0" value="0">
+<span id="step"></span>
+<select id="speed">
+<option value="1000">slow</option>
+<option value="500" selected>normal</option>
+<option value="150">fast</option>
+</select>
+</div>
+</div>
+<div id="side">
+<h3>Run</h3>
+<div id="summary"></div>
+<h3>Paths</h3>
+<div id="paths"></div>
+<h3>Details</h3>
+<div id="details">hover a room or an ant</div>
+<h3>Moves of step</h3>
+<div id="moves"></div>
+</div>
+<script>
+var data = {{.}};
+var NS = "http://www.w3.org/2000/svg";
+var svg = document.getElementById("hive");
+var timeline = document.getElementById("timeline");
+var width = 1000, height = 700, margin = 50;
+var step = 0, timer = null;
+
+// layout: rooms keep proportions of the map
+var minX = Infinity, minY = Infinity, maxX = -Infinity, maxY = -Infinity;
+data.rooms.forEach(function (r) {
+	minX = Math.min(minX, r.x); maxX = Math.max(maxX, r.x);
+	minY = Math.min(minY, r.y); maxY = Math.max(maxY, r.y);
+});
+var scale = Math.min(maxX > minX ? (width - 2 * margin) / (maxX - minX) : Infinity,
+	maxY > minY ? (height - 2 * margin) / (maxY - minY) : Infinity);
+if (!isFinite(scale)) scale = 0;
+var offX = (width - scale * (maxX - minX)) / 2, offY = (height - scale * (maxY - minY)) / 2;
+var point = {}, rooms = {};
+data.rooms.forEach(function (r) {
+	point[r.name] = [offX + scale * (r.x - minX), offY + scale * (r.y - minY)];
+	rooms[r.name] = r;
+});
+svg.setAttribute("viewBox", "0 0 " + width + " " + height);
+
+function el(name, attrs, parent) {
+	var e = document.createElementNS(NS, name);
+	for (var k in attrs) e.setAttribute(k, attrs[k]);
+	(parent || svg).appendChild(e);
+	return e;
+}
+
+function isStart(name) { return data.starts.indexOf(name) >= 0; }
+function isEnd(name) { return data.ends.indexOf(name) >= 0; }
+
+// positions of ants after every step
+var positions = [];
+var pos = data.origin.slice();
+positions.push(pos.slice());
+data.moves.forEach(function (moves) {
+	moves.forEach(function (m) { pos[m.ant] = m.room; });
+	positions.push(pos.slice());
+});
+
+data.links.forEach(function (l, i) {
+	var a = point[l[0]], b = point[l[1]];
+	if (data.oneway[i]) {
+		// arrow in the middle of one-way link
+		var pts = [a, [(a[0] + b[0]) / 2, (a[1] + b[1]) / 2], b].map(function (p) { return p.join(","); });
+		el("polyline", {"class": "link", points: pts.join(" "), fill: "none", "marker-mid": "url(#oneway)"});
+		return;
+	}
+	el("line", {"class": "link", x1: a[0], y1: a[1], x2: b[0], y2: b[1]});
+});
+data.paths.forEach(function (p, i) {
+	var pts = [p.start].concat(p.rooms).map(function (n) { return point[n].join(","); });
+	el("polyline", {points: pts.join(" "), fill: "none", stroke: data.colors[i], "stroke-width": 5, "stroke-opacity": 0.5});
+});
+data.rooms.forEach(function (r) {
+	var cls = "room" + (isStart(r.name) ? " start" : isEnd(r.name) ? " end" : "");
+	var c = el("circle", {"class": cls, cx: point[r.name][0], cy: point[r.name][1], r: 9});
+	c.addEventListener("mouseover", function () { showRoom(r.name); });
+	var t = el("text", {"class": "label", x: point[r.name][0], y: point[r.name][1] - 13, "text-anchor": "middle"});
+	t.textContent = r.name;
+});
+var antLayer = el("g", {});
+var ants = [null];
+for (var i = 1; i <= data.ants; i++) {
+	(function (ant) {
+		var c = el("circle", {"class": "ant", r: 6, fill: data.colors[data.routes[ant]] || "#000"}, antLayer);
+		c.addEventListener("mouseover", function () { showAnt(ant); });
+		ants.push(c);
+	})(i);
+}
+
+function text(s) {
+	var d = document.createElement("div");
+	d.textContent = s;
+	return d.innerHTML;
+}
+
+function showRoom(name) {
+	var r = rooms[name], inside = [];
+	for (var a = 1; a <= data.ants; a++) if (positions[step][a] === name) inside.push(data.names[a]);
+	var role = isStart(name) ? " (start)" : isEnd(name) ? " (end)" : "";
+	document.getElementById("details").innerHTML = "<b>room " + text(name) + role + "</b><br>coordinates: " +
+		r.x + ", " + r.y + "<br>ants: " + (inside.length ? inside.length + " (" + text(inside.join(" ")) + ")" : "none");
+}
+
+function showAnt(ant) {
+	var path = data.paths[data.routes[ant]];
+	document.getElementById("details").innerHTML = "<b>ant " + text(data.names[ant]) + "</b><br>room: " + text(positions[step][ant]) +
+		"<br>path " + (data.routes[ant] + 1) + ": " + text([data.origin[ant]].concat(path ? path.rooms : []).join(" → "));
+}
+
+function render() {
+	var cur = positions[step];
+	var stack = {};
+	for (var a = 1; a <= data.ants; a++) {
+		var p = point[cur[a]];
+		// ants in start and end rooms are stacked
+		var n = stack[cur[a]] = (stack[cur[a]] || 0) + 1;
+		var shift = isStart(cur[a]) || isEnd(cur[a]) ? Math.min(n - 1, 20) * 2 : 0;
+		ants[a].setAttribute("cx", p[0] + shift);
+		ants[a].setAttribute("cy", p[1] - shift);
+	}
+	timeline.value = step;
+	document.getElementById("step").textContent = "step " + step + " / " + data.moves.length;
+	document.getElementById("moves").textContent = step > 0 ?
+		data.moves[step - 1].map(function (m) { return data.names[m.ant] + "-" + m.room; }).join(" ") : "";
+}
+
+function stop() {
+	clearInterval(timer);
+	timer = null;
+	document.getElementById("play").textContent = "play";
+}
+
+function play() {
+	if (step >= data.moves.length) step = 0;
+	document.getElementById("play").textContent = "pause";
+	timer = setInterval(function () {
+		if (step >= data.moves.length) return stop();
+		step++;
+		render();
+	}, +document.getElementById("speed").value);
+}
+
+document.getElementById("play").onclick = function () { timer ? stop() : play(); };
+document.getElementById("prev").onclick = function () { stop(); if (step > 0) step--; render(); };
+document.getElementById("next").onclick = function () { stop(); if (step < data.moves.length) step++; render(); };
+document.getElementById("speed").onchange = function () { if (timer) { stop(); play(); } };
+timeline.max = data.moves.length;
+timeline.oninput = function () { stop(); step = +timeline.value; render(); };
+
+document.getElementById("summary").innerHTML = data.ants + " ants, " + data.moves.length + " steps<br>start: " +
+	text(data.starts.join(", ")) + "<br>end: " + text(data.ends.join(", "));
+document.getElementById("paths").innerHTML = data.paths.map(function (p, i) {
+	return "<div style=\"color:" + data.colors[i] + "\">path " + (i + 1) + ": " + p.rooms.length + " rooms, " +
+		p.ants + " ants</div>";
+}).join("");
+render();
+</script>
+</body>
+</html>
+`))
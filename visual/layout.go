@@ -0,0 +1,145 @@
+package visual
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"leminmod/anthive"
+)
+
+// bounds - min and max coordinates of rooms
+type bounds struct {
+	MinX, MinY, MaxX, MaxY int
+}
+
+func getBounds(m *anthive.Map) bounds {
+	if len(m.Rooms) == 0 {
+		return bounds{}
+	}
+	b := bounds{m.Rooms[0].X, m.Rooms[0].Y, m.Rooms[0].X, m.Rooms[0].Y}
+	for _, r := range m.Rooms {
+		if r.X < b.MinX {
+			b.MinX = r.X
+		}
+		if r.X > b.MaxX {
+			b.MaxX = r.X
+		}
+		if r.Y < b.MinY {
+			b.MinY = r.Y
+		}
+		if r.Y > b.MaxY {
+			b.MaxY = r.Y
+		}
+	}
+	return b
+}
+
+// project - converts coordinates of room into point of area with size width x height
+func (b bounds) project(x, y int, width, height float64) (float64, float64) {
+	px, py := width/2, height/2
+	if b.MaxX > b.MinX {
+		px = float64(x-b.MinX) * width / float64(b.MaxX-b.MinX)
+	}
+	if b.MaxY > b.MinY {
+		py = float64(y-b.MinY) * height / float64(b.MaxY-b.MinY)
+	}
+	return px, py
+}
+
+// antRoutes - returns index of route for each ant (index is ant number).
+// Routes are numbered in order of appearance, ants with the same start room and rooms sequence have the same route
+func antRoutes(m *anthive.Map, moves [][]anthive.Move) []int {
+	antsCount := m.AntsCount
+	starts := m.AntStarts(moves)
+	sequences := make([][]string, antsCount+1)
+	for ant := range sequences {
+		sequences[ant] = []string{starts[ant]}
+	}
+	for _, step := range moves {
+		for _, move := range step {
+			if move.Ant < len(sequences) {
+				sequences[move.Ant] = append(sequences[move.Ant], move.Room)
+			}
+		}
+	}
+	result := make([]int, antsCount+1)
+	indexes := make(map[string]int)
+	for ant := 1; ant <= antsCount; ant++ {
+		key := strings.Join(sequences[ant], "-")
+		if _, ok := indexes[key]; !ok {
+			indexes[key] = len(indexes)
+		}
+		result[ant] = indexes[key]
+	}
+	return result
+}
+
+// positions - returns room of each ant after step (index is ant number). Step 0 is initial state
+func positions(m *anthive.Map, moves [][]anthive.Move, step int) []string {
+	result := m.AntStarts(moves)
+	for i := 0; i < step && i < len(moves); i++ {
+		for _, move := range moves[i] {
+			if move.Ant < len(result) {
+				result[move.Ant] = move.Room
+			}
+		}
+	}
+	return result
+}
+
+// Colors of paths for images
+var pathColors = []color.RGBA{
+	{0xe6, 0x19, 0x4b, 0xff},
+	{0x3c, 0xb4, 0x4b, 0xff},
+	{0x43, 0x63, 0xd8, 0xff},
+	{0xf5, 0x82, 0x31, 0xff},
+	{0x91, 0x1e, 0xb4, 0xff},
+	{0x42, 0xd4, 0xf4, 0xff},
+	{0xf0, 0x32, 0xe6, 0xff},
+	{0xbf, 0xef, 0x45, 0xff},
+	{0x46, 0x99, 0x90, 0xff},
+	{0x9a, 0x63, 0x24, 0xff},
+	{0x80, 0x00, 0x00, 0xff},
+	{0x00, 0x00, 0x75, 0xff},
+}
+
+func pathColor(i int) color.RGBA {
+	return pathColors[i%len(pathColors)]
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// imagePoints - returns position of each room on image with size width x height and margin.
+// Proportions of the map are kept, the map is centered
+func imagePoints(m *anthive.Map, width, height, margin int) map[string][2]float64 {
+	b := getBounds(m)
+	areaWidth, areaHeight := float64(width-2*margin), float64(height-2*margin)
+	if areaWidth < 0 {
+		areaWidth = 0
+	}
+	if areaHeight < 0 {
+		areaHeight = 0
+	}
+	scale := 0.0
+	if b.MaxX > b.MinX {
+		scale = areaWidth / float64(b.MaxX-b.MinX)
+	}
+	if b.MaxY > b.MinY {
+		if s := areaHeight / float64(b.MaxY-b.MinY); s < scale || b.MaxX == b.MinX {
+			scale = s
+		}
+	}
+	offsetX := float64(margin) + (areaWidth-scale*float64(b.MaxX-b.MinX))/2
+	offsetY := float64(margin) + (areaHeight-scale*float64(b.MaxY-b.MinY))/2
+	result := make(map[string][2]float64)
+	for _, r := range m.Rooms {
+		result[r.Name] = [2]float64{
+			offsetX + scale*float64(r.X-b.MinX),
+			offsetY + scale*float64(r.Y-b.MinY),
+		}
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package visual
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"leminmod/anthive"
+)
+
+// Sizes of svg elements
+const (
+	svgMargin     = 60
+	svgRoomRadius = 8
+)
+
+// WriteSVG - draws the map of result with found paths as svg image with size width x height
+func WriteSVG(w io.Writer, result *anthive.Result, width, height int) error {
+	m := result.Map
+	points := imagePoints(m, width, height, svgMargin)
+	routes := result.Routes()
+	ants := result.AntsPerPath()
+	starts := result.Starts()
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"12\">\n",
+		width, height, width, height)
+	fmt.Fprintf(out, "<rect width=\"100%%\" height=\"100%%\" fill=\"#ffffff\"/>\n")
+	fmt.Fprintf(out, "<text x=\"10\" y=\"20\" font-size=\"14\" font-weight=\"bold\">%d ants, %d paths, %d steps</text>\n",
+		m.AntsCount, len(routes), len(result.Moves()))
+
+	// tunnels, one-way tunnel has arrow in the middle
+	fmt.Fprintln(out, "<defs><marker id=\"oneway\" viewBox=\"0 0 10 10\" refX=\"5\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\">"+
+		"<path d=\"M0,0 L10,5 L0,10 z\" fill=\"#888888\"/></marker></defs>")
+	fmt.Fprintln(out, "<g stroke=\"#bbbbbb\" stroke-width=\"2\">")
+	for _, l := range m.Links {
+		from, to := points[l.From], points[l.To]
+		if l.OneWay {
+			fmt.Fprintf(out, "<polyline points=\"%.1f,%.1f %.1f,%.1f %.1f,%.1f\" fill=\"none\" marker-mid=\"url(#oneway)\"/>\n",
+				from[0], from[1], (from[0]+to[0])/2, (from[1]+to[1])/2, to[0], to[1])
+			continue
+		}
+		fmt.Fprintf(out, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>\n", from[0], from[1], to[0], to[1])
+	}
+	fmt.Fprintln(out, "</g>")
+
+	// paths
+	for i, route := range routes {
+		c := hexColor(pathColor(i))
+		coords := make([]string, 0, len(route)+1)
+		for _, name := range append([]string{starts[i]}, route...) {
+			coords = append(coords, fmt.Sprintf("%.1f,%.1f", points[name][0], points[name][1]))
+		}
+		fmt.Fprintf(out, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"5\" stroke-opacity=\"0.8\" stroke-linejoin=\"round\"/>\n",
+			strings.Join(coords, " "), c)
+		// count of ants near first room of path
+		first := points[route[0]]
+		fmt.Fprintf(out, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\" font-weight=\"bold\">%d ants</text>\n",
+			first[0]+svgRoomRadius+2, first[1]+svgRoomRadius+14, c, ants[i])
+	}
+
+	// rooms
+	for _, r := range m.Rooms {
+		pt := points[r.Name]
+		fill, radius, label := "#ffffff", svgRoomRadius, r.Name
+		if m.IsStart(r.Name) {
+			fill, radius, label = "#2e7d32", svgRoomRadius+4, r.Name+" (start)"
+		} else if m.IsEnd(r.Name) {
+			fill, radius, label = "#c62828", svgRoomRadius+4, r.Name+" (end)"
+		}
+		fmt.Fprintf(out, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"%d\" fill=\"%s\" stroke=\"#333333\" stroke-width=\"2\"><title>%s (%d, %d)</title></circle>\n",
+			pt[0], pt[1], radius, fill, html.EscapeString(r.Name), r.X, r.Y)
+		fmt.Fprintf(out, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\">%s</text>\n",
+			pt[0], pt[1]-float64(radius)-4, html.EscapeString(label))
+	}
+
+	// legend
+	for i, route := range routes {
+		y := height - 14*(len(routes)-i) - 6
+		fmt.Fprintf(out, "<rect x=\"10\" y=\"%d\" width=\"10\" height=\"10\" fill=\"%s\"/>\n", y-9, hexColor(pathColor(i)))
+		fmt.Fprintf(out, "<text x=\"26\" y=\"%d\">path %d: %d rooms, %d ants</text>\n", y, i+1, len(route), ants[i])
+	}
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
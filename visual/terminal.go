@@ -0,0 +1,325 @@
+package visual
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"leminmod/anthive"
+)
+
+// ANSI colors of routes
+var termColors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+// Limits of Player.Delay for speed controls
+const (
+	minDelay = 25 * time.Millisecond
+	maxDelay = 5 * time.Second
+)
+
+// Player - animates moves of ants on terminal. Rooms are placed on character grid by their coordinates
+type Player struct {
+	Map           *anthive.Map
+	Moves         [][]anthive.Move
+	Width, Height int           // Size of grid in characters
+	LabelWidth    int           // Max length of room name on grid
+	Delay         time.Duration // Delay between steps
+	Plain         bool          // Without colors and clearing of screen
+}
+
+// cell of terminal grid
+type cell struct {
+	Char  rune
+	Color int // ANSI color, 0 is default
+	Bold  bool
+}
+
+// Play - draws steps into w. Controls are read from keys, if keys is nil then plays to the end without pauses
+// Controls: space - pause/resume, n - next step, b - previous step, + / - speed, r - restart, q - quit
+func (p *Player) Play(w io.Writer, keys <-chan byte) error {
+	out := bufio.NewWriter(w)
+	routes := antRoutes(p.Map, p.Moves)
+	step, paused := 0, false
+	for {
+		p.draw(out, step, paused, routes, keys != nil)
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		var timer <-chan time.Time
+		if !paused && step < len(p.Moves) {
+			timer = time.After(p.Delay)
+		} else if keys == nil {
+			return nil
+		}
+		select {
+		case <-timer:
+			step++
+		case key, ok := <-keys:
+			if !ok {
+				keys = nil
+				continue
+			}
+			switch key {
+			case ' ', 'p':
+				paused = !paused
+			case 'n', '.':
+				paused = true
+				if step < len(p.Moves) {
+					step++
+				}
+			case 'b', ',':
+				paused = true
+				if step > 0 {
+					step--
+				}
+			case '+', '=':
+				if p.Delay /= 2; p.Delay < minDelay {
+					p.Delay = minDelay
+				}
+			case '-', '_':
+				if p.Delay *= 2; p.Delay > maxDelay {
+					p.Delay = maxDelay
+				}
+			case 'r':
+				step = 0
+			case 'q', 3: // 3 is Ctrl+C in raw mode
+				return nil
+			}
+		}
+	}
+}
+
+// draw - writes frame of step
+func (p *Player) draw(w *bufio.Writer, step int, paused bool, routes []int, controls bool) {
+	if !p.Plain {
+		fmt.Fprint(w, "\x1b[H\x1b[2J")
+	}
+	pos := positions(p.Map, p.Moves, step)
+	inStart, inEnd := 0, 0
+	for _, name := range pos[1:] {
+		if p.Map.IsStart(name) {
+			inStart++
+		} else if p.Map.IsEnd(name) {
+			inEnd++
+		}
+	}
+	state := "playing"
+	if paused {
+		state = "paused"
+	} else if step == len(p.Moves) {
+		state = "finished"
+	}
+	fmt.Fprintf(w, "Step %d/%d [%s] delay %v | start %s: %d ants | end %s: %d ants\n",
+		step, len(p.Moves), state, p.Delay, strings.Join(p.Map.Starts, ","), inStart, strings.Join(p.Map.Ends, ","), inEnd)
+
+	for _, line := range p.grid(pos, step, routes) {
+		for _, c := range line {
+			if p.Plain || c.Color == 0 && !c.Bold {
+				w.WriteRune(c.Char)
+				continue
+			}
+			bold := ""
+			if c.Bold {
+				bold = "1;"
+			}
+			fmt.Fprintf(w, "\x1b[%s%dm%c\x1b[0m", bold, c.Color, c.Char)
+		}
+		w.WriteByte('\n')
+	}
+
+	if step > 0 {
+		for _, move := range p.Moves[step-1] {
+			p.writeColored(w, move.String()+" ", p.routeColor(routes, move.Ant))
+		}
+	}
+	w.WriteByte('\n')
+	if controls {
+		fmt.Fprintln(w, "space: pause | n: next | b: back | +/-: speed | r: restart | q: quit")
+	} else if p.Plain {
+		w.WriteByte('\n')
+	}
+}
+
+// grid - returns character grid with tunnels, rooms and ants
+func (p *Player) grid(pos []string, step int, routes []int) [][]cell {
+	width, height := p.Width, p.Height
+	if width < 2 {
+		width = 2
+	}
+	if height < 2 {
+		height = 2
+	}
+	result := make([][]cell, height)
+	for i := range result {
+		result[i] = make([]cell, width)
+		for j := range result[i] {
+			result[i][j].Char = ' '
+		}
+	}
+	labelWidth := p.LabelWidth
+	if labelWidth < 1 {
+		labelWidth = 1
+	}
+	areaWidth := width - labelWidth
+	if areaWidth < 1 {
+		areaWidth = 1
+	}
+	b := getBounds(p.Map)
+	points := make(map[string][2]int)
+	for _, r := range p.Map.Rooms {
+		x, y := b.project(r.X, r.Y, float64(areaWidth-1), float64(height-1))
+		points[r.Name] = [2]int{int(x + 0.5), int(y + 0.5)}
+	}
+
+	for _, l := range p.Map.Links {
+		drawLine(result, points[l.From], points[l.To], 0)
+	}
+	// tunnels used on this step
+	if step > 0 {
+		prev := positions(p.Map, p.Moves, step-1)
+		for _, move := range p.Moves[step-1] {
+			if from, ok := points[prev[move.Ant]]; ok {
+				drawLine(result, from, points[move.Room], p.routeColor(routes, move.Ant))
+			}
+		}
+	}
+
+	occupant := make(map[string]int)
+	for ant := 1; ant < len(pos); ant++ {
+		if !p.Map.IsStart(pos[ant]) && !p.Map.IsEnd(pos[ant]) {
+			occupant[pos[ant]] = ant
+		}
+	}
+	for _, r := range p.Map.Rooms {
+		label, color, bold := r.Name, 0, false
+		if ant, ok := occupant[r.Name]; ok {
+			label, color, bold = p.Map.AntName(ant), p.routeColor(routes, ant), true
+		} else if p.Map.IsStart(r.Name) || p.Map.IsEnd(r.Name) {
+			bold = true
+		}
+		if len(label) > labelWidth {
+			label = label[:labelWidth]
+		}
+		pt := points[r.Name]
+		for i, ch := range label {
+			if pt[0]+i < width {
+				result[pt[1]][pt[0]+i] = cell{Char: ch, Color: color, Bold: bold}
+			}
+		}
+	}
+	return result
+}
+
+func (p *Player) routeColor(routes []int, ant int) int {
+	if ant >= len(routes) {
+		return 0
+	}
+	return termColors[routes[ant]%len(termColors)]
+}
+
+func (p *Player) writeColored(w *bufio.Writer, s string, color int) {
+	if p.Plain || color == 0 {
+		w.WriteString(s)
+		return
+	}
+	fmt.Fprintf(w, "\x1b[%dm%s\x1b[0m", color, s)
+}
+
+// drawLine - draws tunnel between points with Bresenham's line algorithm
+func drawLine(grid [][]cell, from, to [2]int, color int) {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	char := lineChar(dx, dy)
+	x, y := from[0], from[1]
+	stepX, stepY := 1, 1
+	if dx < 0 {
+		stepX, dx = -1, -dx
+	}
+	if dy < 0 {
+		stepY, dy = -1, -dy
+	}
+	e := dx - dy
+	for {
+		if (x != from[0] || y != from[1]) && (x != to[0] || y != to[1]) {
+			if color != 0 || grid[y][x].Char == ' ' {
+				grid[y][x] = cell{Char: char, Color: color}
+			}
+		}
+		if x == to[0] && y == to[1] {
+			return
+		}
+		e2 := 2 * e
+		if e2 > -dy {
+			e -= dy
+			x += stepX
+		}
+		if e2 < dx {
+			e += dx
+			y += stepY
+		}
+	}
+}
+
+// lineChar - returns character of tunnel by its direction
+func lineChar(dx, dy int) rune {
+	adx, ady := dx, dy
+	if adx < 0 {
+		adx = -adx
+	}
+	if ady < 0 {
+		ady = -ady
+	}
+	switch {
+	case ady*2 < adx:
+		return '-'
+	case adx*2 < ady:
+		return '|'
+	case (dx > 0) == (dy > 0):
+		return '\\'
+	default:
+		return '/'
+	}
+}
+
+// IsTerminal - returns true if file is terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ReadKeys - switches terminal into raw mode (with stty) and sends pressed keys into channel.
+// restore must be called for return terminal into previous mode
+func ReadKeys(f *os.File) (keys <-chan byte, restore func(), err error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReadKeys: %w", err)
+	}
+	if _, err = stty(f, "cbreak", "-echo"); err != nil {
+		return nil, nil, fmt.Errorf("ReadKeys: %w", err)
+	}
+	ch := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if n, err := f.Read(buf); err != nil || n == 0 {
+				close(ch)
+				return
+			}
+			ch <- buf[0]
+		}
+	}()
+	restore = func() {
+		stty(f, strings.TrimSpace(saved))
+	}
+	return ch, restore, nil
+}
+
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}